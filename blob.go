@@ -0,0 +1,247 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/lock"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// number of pages a blob writer keeps in memory before it commits them
+const blobCommitPages = 256
+
+// BlobWriter streams a value into overflow pages, the value is committed as
+// a new version of the key when the writer is closed. The writer locks the
+// key until it is closed or aborted. The pages are committed in batches, the
+// other writes only wait for the commit of a batch. The batches committed
+// before a crash are freed when the database is opened again.
+type BlobWriter struct {
+	db *DB
+	// owner of the lock of the key
+	owner  uint64
+	closed bool
+	key    []byte
+	writer *pg.OverflowWriter
+	// pages of the writer committed by earlier batches
+	committedPages int
+	err            error
+}
+
+// BlobReader reads a value on demand without loading it into memory. The
+// reader holds a read only transaction until it is closed, the version it
+// reads and its pages are kept until then.
+type BlobReader struct {
+	tx     *Tx
+	reader io.ReadSeeker
+}
+
+// OpenBlobWriter returns a writer storing its data under the key, an
+// existing value of the key is replaced on Close
+func (db *DB) OpenBlobWriter(key []byte) (*BlobWriter, error) {
+	if len(key) > node.MAX_KEY_SIZE {
+		return nil, ErrKeyTooLarge
+	}
+
+	if err := db.enter(); err != nil {
+		return nil, err
+	}
+
+	w := &BlobWriter{db: db, owner: db.locks.NewOwner(), key: bytes.Clone(key)}
+	if err := db.locks.Lock(w.owner, lock.Key(w.key), lock.Exclusive, db.lockTimeout); err != nil {
+		db.locks.ReleaseAll(w.owner)
+		db.leave()
+		return nil, err
+	}
+
+	w.writer = db.pager.NewOverflowWriter()
+	return w, nil
+}
+
+func (w *BlobWriter) Write(data []byte) (int, error) {
+	if w.closed {
+		return 0, ErrBlobClosed
+	}
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	// large writes are split so at most one batch is kept in memory
+	batchSize := blobCommitPages * int(w.db.pager.OverflowPageCapacity())
+	written := 0
+	for written < len(data) {
+		batchWritten, writeErr := w.writer.Write(data[written:min(len(data), written+batchSize)])
+		written += batchWritten
+		if writeErr == nil && w.writer.BufferedPages() > blobCommitPages {
+			writeErr = w.commitBatch()
+		}
+
+		if writeErr != nil {
+			// the blob is discarded on Close after a failed write
+			w.err = writeErr
+			return written, writeErr
+		}
+	}
+
+	return written, nil
+}
+
+// Close stores the blob under the key and commits it, the blob is discarded
+// when a write failed
+func (w *BlobWriter) Close() error {
+	if w.closed {
+		return ErrBlobClosed
+	}
+
+	if w.err != nil {
+		w.abort()
+		return w.err
+	}
+
+	if err := w.finish(); err != nil {
+		w.abort()
+		return err
+	}
+
+	w.release()
+	return nil
+}
+
+// Abort discards the blob and leaves the value of the key unchanged
+func (w *BlobWriter) Abort() error {
+	if w.closed {
+		return ErrBlobClosed
+	}
+
+	return w.abort()
+}
+
+func (w *BlobWriter) release() {
+	w.closed = true
+	w.db.locks.ReleaseAll(w.owner)
+	w.db.leave()
+}
+
+// commitBatch writes the full pages kept in memory in a transaction of their
+// own, the chain is pending until finish commits the value
+func (w *BlobWriter) commitBatch() error {
+	w.db.writers.Lock()
+	defer w.db.writers.Unlock()
+
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	w.committedPages = len(w.writer.PageIds())
+
+	return nil
+}
+
+func (w *BlobWriter) finish() error {
+	db := w.db
+	db.writers.Lock()
+	defer db.writers.Unlock()
+
+	timestamp := db.oracle.Next()
+	defer db.oracle.Finish(timestamp)
+
+	return db.pager.Atomic(func() error {
+		pageId, length, closeErr := w.writer.Close()
+		if closeErr != nil {
+			return closeErr
+		}
+
+		versions, getErr := getVersions(db.pager, w.key)
+		if getErr != nil {
+			return getErr
+		}
+
+		db.pager.SetCommitTimestamp(timestamp)
+		pointer := &node.OverflowPointer{PageId: pageId, Length: length}
+		version := mvcc.Version{Timestamp: timestamp, Blob: true, Data: pointer.Encode()}
+		return db.writeVersion(db.pager, w.key, version, versions)
+	})
+}
+
+// abort frees the pages of the batches committed before, the pages of the
+// current batch were never written
+func (w *BlobWriter) abort() error {
+	defer w.release()
+
+	if w.committedPages == 0 {
+		return nil
+	}
+
+	w.db.writers.Lock()
+	defer w.db.writers.Unlock()
+
+	return w.db.pager.FreePendingOverflow(w.writer.PageIds()[:w.committedPages])
+}
+
+// OpenBlobReader returns a reader over the value of the key, values stored
+// with a transaction or a BlobWriter can be read
+func (db *DB) OpenBlobReader(key []byte) (*BlobReader, error) {
+	tx, beginErr := db.Begin(false)
+	if beginErr != nil {
+		return nil, beginErr
+	}
+
+	reader, openErr := tx.openValue(key)
+	if openErr != nil {
+		tx.Rollback()
+		return nil, openErr
+	}
+
+	return &BlobReader{tx: tx, reader: reader}, nil
+}
+
+// openValue returns a reader over the version of the key the transaction
+// sees, a blob is read from its overflow pages on demand
+func (tx *Tx) openValue(key []byte) (io.ReadSeeker, error) {
+	version, found, readErr := tx.read(key)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	if !version.Blob {
+		return bytes.NewReader(version.Data), nil
+	}
+
+	pointer, decodeErr := node.DecodeOverflowPointer(version.Data)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return tx.pager.NewOverflowReader(pointer.PageId, pointer.Length), nil
+}
+
+func (r *BlobReader) Read(buf []byte) (int, error) {
+	if r.tx.closed {
+		return 0, ErrBlobClosed
+	}
+
+	return r.reader.Read(buf)
+}
+
+func (r *BlobReader) Seek(offset int64, whence int) (int64, error) {
+	if r.tx.closed {
+		return 0, ErrBlobClosed
+	}
+
+	return r.reader.Seek(offset, whence)
+}
+
+// Close releases the read only transaction of the reader
+func (r *BlobReader) Close() error {
+	if r.tx.closed {
+		return ErrBlobClosed
+	}
+
+	return r.tx.Commit()
+}
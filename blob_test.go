@@ -0,0 +1,183 @@
+package bricker
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func blobData(length int) []byte {
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	return data
+}
+
+func writeBlob(t *testing.T, db *DB, key []byte, data []byte) {
+	writer, openErr := db.OpenBlobWriter(key)
+	assert.Nil(t, openErr)
+
+	// write in chunks which do not line up with the pages
+	for start := 0; start < len(data); start += 1000 {
+		_, writeErr := writer.Write(data[start:min(len(data), start+1000)])
+		assert.Nil(t, writeErr)
+	}
+	assert.Nil(t, writer.Close())
+}
+
+func TestBlobWriteAndRead(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+
+	data := blobData(3 << 20)
+	writeBlob(t, db, testKey(1), data)
+	assert.Nil(t, db.Close())
+
+	db, openErr = Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	reader, readerErr := db.OpenBlobReader(testKey(1))
+	assert.Nil(t, readerErr)
+	readData, readErr := io.ReadAll(reader)
+	assert.Nil(t, readErr)
+	assert.True(t, bytes.Equal(data, readData))
+
+	position, seekErr := reader.Seek(1<<20, io.SeekStart)
+	assert.Nil(t, seekErr)
+	assert.Equal(t, int64(1<<20), position)
+	buf := make([]byte, 100)
+	_, readErr = io.ReadFull(reader, buf)
+	assert.Nil(t, readErr)
+	assert.Equal(t, data[1<<20:(1<<20)+100], buf)
+	assert.Nil(t, reader.Close())
+
+	_, readErr = reader.Read(buf)
+	assert.ErrorIs(t, readErr, ErrBlobClosed)
+	assert.ErrorIs(t, reader.Close(), ErrBlobClosed)
+}
+
+func TestBlobReaderReadsRegularValues(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Insert(testKey(1), []byte("value")))
+	assert.Nil(t, tx.Commit())
+
+	reader, readerErr := db.OpenBlobReader(testKey(1))
+	assert.Nil(t, readerErr)
+	data, readErr := io.ReadAll(reader)
+	assert.Nil(t, readErr)
+	assert.Equal(t, []byte("value"), data)
+	assert.Nil(t, reader.Close())
+
+	_, readerErr = db.OpenBlobReader(testKey(2))
+	assert.ErrorIs(t, readerErr, ErrKeyNotFound)
+}
+
+func TestBlobWriterReplacesValue(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	writeBlob(t, db, testKey(1), blobData(1<<20))
+	writeBlob(t, db, testKey(1), blobData(1<<19))
+	_, vacuumErr := db.Vacuum()
+	assert.Nil(t, vacuumErr)
+	pageCount := db.pager.PageCount()
+
+	// the new blob reuses the pages freed by the first one once the vacuum
+	// purged it
+	data := blobData(1 << 19)
+	writeBlob(t, db, testKey(1), data)
+	assert.Equal(t, pageCount, db.pager.PageCount())
+
+	tx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	readData, getErr := tx.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.True(t, bytes.Equal(data, readData))
+	assert.Nil(t, tx.Commit())
+}
+
+func TestBlobWriterAbort(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	pageCount := db.pager.PageCount()
+	writer, writerErr := db.OpenBlobWriter(testKey(1))
+	assert.Nil(t, writerErr)
+
+	// large enough to commit several batches before the abort
+	_, writeErr := writer.Write(blobData(3 << 20))
+	assert.Nil(t, writeErr)
+	assert.Nil(t, writer.Abort())
+	assert.ErrorIs(t, writer.Close(), ErrBlobClosed)
+	_, writeErr = writer.Write([]byte("data"))
+	assert.ErrorIs(t, writeErr, ErrBlobClosed)
+
+	assert.Equal(t, db.pager.PageCount()-pageCount, db.pager.FreePageCount())
+
+	_, readerErr := db.OpenBlobReader(testKey(1))
+	assert.ErrorIs(t, readerErr, ErrKeyNotFound)
+}
+
+func TestBlobWriterDoesNotBlockWrites(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	writer, writerErr := db.OpenBlobWriter(testKey(1))
+	assert.Nil(t, writerErr)
+	data := blobData(3 << 20)
+	_, writeErr := writer.Write(data[:(2 << 20)])
+	assert.Nil(t, writeErr)
+
+	// the puts and the commits run while the blob is written
+	assert.Nil(t, db.Put(testKey(2), []byte("value2")))
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Insert(testKey(3), []byte("value3")))
+	assert.Nil(t, tx.Commit())
+
+	_, writeErr = writer.Write(data[(2 << 20):])
+	assert.Nil(t, writeErr)
+	assert.Nil(t, writer.Close())
+
+	readData, getErr := db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.True(t, bytes.Equal(data, readData))
+	for key, expected := range map[uint32]string{2: "value2", 3: "value3"} {
+		value, getErr := db.Get(testKey(key))
+		assert.Nil(t, getErr)
+		assert.Equal(t, []byte(expected), value)
+	}
+}
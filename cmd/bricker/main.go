@@ -0,0 +1,61 @@
+package main
+
+import (
+	bricker "bricker-db"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const usage = `usage: bricker <database> get <key>
+       bricker <database> put <key> <value>
+       bricker <database> delete <key>`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) < 3 {
+		return errors.New(usage)
+	}
+
+	path, command, key := args[0], args[1], []byte(args[2])
+	if command == "put" && len(args) != 4 || command != "put" && len(args) != 3 {
+		return errors.New(usage)
+	}
+
+	db, openErr := bricker.Open(path, nil)
+	if openErr != nil {
+		return openErr
+	}
+
+	commandErr := runCommand(db, command, key, args[3:])
+	if err := db.Close(); err != nil && commandErr == nil {
+		return err
+	}
+
+	return commandErr
+}
+
+func runCommand(db *bricker.DB, command string, key []byte, args []string) error {
+	switch command {
+	case "get":
+		value, getErr := db.Get(key)
+		if getErr != nil {
+			return getErr
+		}
+
+		fmt.Println(string(value))
+		return nil
+	case "put":
+		return db.Put(key, []byte(args[0]))
+	case "delete":
+		return db.Delete(key)
+	default:
+		return errors.New(usage)
+	}
+}
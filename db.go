@@ -0,0 +1,362 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/lock"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Comparator defines the order of the keys, a database must be reopened
+// with the comparator it was created with
+type Comparator = node.Comparator
+
+// BytewiseComparator orders keys lexicographically
+var BytewiseComparator = node.BytewiseComparator
+
+// SyncMode controls when committed transactions are flushed to the disk
+type SyncMode = pg.SyncMode
+
+const (
+	// SyncFull makes every transaction durable once it is committed
+	SyncFull = pg.SyncFull
+	// SyncOff trades the durability of the last committed transactions for
+	// faster commits, a crash never leaves a transaction half applied
+	SyncOff = pg.SyncOff
+)
+
+const DEFAULT_VACUUM_INTERVAL = time.Minute
+const DEFAULT_LOCK_TIMEOUT = 10 * time.Second
+
+// Options of a database, the zero fields are filled from DefaultOptions
+type Options struct {
+	// size of the pages of a new database, a power of two between 4KB and
+	// 64KB, existing databases keep the page size they were created with
+	PageSize uint32
+	// number of pages kept in memory, a zero size uses the size of
+	// DefaultOptions and a negative one is rejected
+	CacheSize  int
+	SyncMode   SyncMode
+	Comparator Comparator
+	// interval of the background vacuum purging the versions no transaction
+	// can read, a negative interval disables it
+	VacuumInterval time.Duration
+	// time a write waits for the locks of other transactions before failing
+	// with ErrLockTimeout, a zero timeout uses DEFAULT_LOCK_TIMEOUT and a
+	// negative one waits without limit. Transactions can change it with
+	// SetLockTimeout.
+	LockTimeout time.Duration
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		PageSize:       pg.DEFAULT_PAGE_SIZE,
+		CacheSize:      pg.DEFAULT_BUFFER_POOL_SIZE,
+		SyncMode:       SyncFull,
+		Comparator:     BytewiseComparator,
+		VacuumInterval: DEFAULT_VACUUM_INTERVAL,
+		LockTimeout:    DEFAULT_LOCK_TIMEOUT,
+	}
+}
+
+// withDefaults returns a copy of the options with the zero fields taken from
+// DefaultOptions
+func (options *Options) withDefaults() *Options {
+	defaults := DefaultOptions()
+	if options == nil {
+		return defaults
+	}
+
+	filled := *options
+	if filled.PageSize == 0 {
+		filled.PageSize = defaults.PageSize
+	}
+	if filled.CacheSize == 0 {
+		filled.CacheSize = defaults.CacheSize
+	}
+	if filled.Comparator == nil {
+		filled.Comparator = defaults.Comparator
+	}
+	if filled.VacuumInterval == 0 {
+		filled.VacuumInterval = defaults.VacuumInterval
+	}
+	if filled.LockTimeout == 0 {
+		filled.LockTimeout = defaults.LockTimeout
+	}
+
+	return &filled
+}
+
+// DB is a B+ tree stored in a single file. Every value is kept as a list of
+// versions tagged with the timestamp of the commit that wrote them, the
+// transactions read the versions of the snapshot taken when they began and
+// run concurrently with each other and with the puts. A writable transaction
+// fails to commit when another commit wrote one of its keys after it began,
+// the writes wait for the locks other transactions hold on their keys. The
+// versions no transaction can read anymore are purged by Vacuum.
+type DB struct {
+	// guards closed, Close waits for the transactions, puts and blob writers
+	// counted in active and new ones fail once closed is set
+	mu     sync.Mutex
+	closed bool
+	active sync.WaitGroup
+	// held shared by the puts and exclusively by the commits of the
+	// writable transactions, the batches of the blob writers and the vacuum
+	writers sync.RWMutex
+	// key and range locks of the transactions, the puts and the blob
+	// writers, they are taken before the writers lock
+	locks       *lock.Manager
+	lockTimeout time.Duration
+	oracle      *mvcc.Oracle
+	pager       *pg.Pager
+
+	stopVacuum chan struct{}
+	stopOnce   sync.Once
+	vacuumDone sync.WaitGroup
+}
+
+// Open opens the database stored in the file at path and creates it when it
+// does not exist. Nil options use DefaultOptions, the zero fields of the
+// options are taken from DefaultOptions too.
+func Open(path string, options *Options) (*DB, error) {
+	options = options.withDefaults()
+	if options.CacheSize < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidCacheSize, options.CacheSize)
+	}
+
+	pagerOptions := pg.DefaultOptions()
+	pagerOptions.PageSize = options.PageSize
+	pagerOptions.BufferPoolSize = options.CacheSize
+	pagerOptions.Comparator = options.Comparator
+	pagerOptions.SyncMode = options.SyncMode
+
+	pager, pagerErr := pg.NewPagerWithOptions(path, pagerOptions)
+	if pagerErr != nil {
+		return nil, fmt.Errorf("failed to open database: %w", pagerErr)
+	}
+
+	if err := operations.Init(pager); err != nil {
+		pager.CloseFile()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	if err := upgradeValues(pager); err != nil {
+		pager.CloseFile()
+		return nil, fmt.Errorf("failed to upgrade database: %w", err)
+	}
+
+	db := &DB{
+		locks:       lock.NewManager(pager.Comparator()),
+		lockTimeout: options.LockTimeout,
+		oracle:      mvcc.NewOracle(pager.CommitTimestamp()),
+		pager:       pager,
+		stopVacuum:  make(chan struct{}),
+	}
+	if options.VacuumInterval > 0 {
+		db.vacuumDone.Add(1)
+		go db.vacuumEvery(options.VacuumInterval)
+	}
+
+	return db, nil
+}
+
+// Close waits for the open transactions to finish and closes the file, the
+// transactions begun while it waits fail with ErrDatabaseClosed
+func (db *DB) Close() error {
+	// the vacuum is stopped first, it begins transactions itself
+	db.stopOnce.Do(func() { close(db.stopVacuum) })
+	db.vacuumDone.Wait()
+
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return ErrDatabaseClosed
+	}
+	db.closed = true
+	db.mu.Unlock()
+
+	db.active.Wait()
+	return db.pager.CloseFile()
+}
+
+// enter counts a transaction, put or blob writer until leave is called, it
+// fails once Close was called
+func (db *DB) enter() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrDatabaseClosed
+	}
+
+	db.active.Add(1)
+	return nil
+}
+
+func (db *DB) leave() {
+	db.active.Done()
+}
+
+// Begin starts a transaction reading a snapshot of the last commit, the
+// changes of a writable transaction are kept in memory until it commits
+func (db *DB) Begin(writable bool) (*Tx, error) {
+	if err := db.enter(); err != nil {
+		return nil, err
+	}
+
+	// the commits up to the timestamp finished before it became visible,
+	// the view taken afterwards holds their versions
+	timestamp := db.oracle.Snapshot()
+	tx := &Tx{db: db, pager: db.pager.BeginRead(), timestamp: timestamp, writable: writable}
+
+	tx.owner = db.locks.NewOwner()
+	tx.lockTimeout = db.lockTimeout
+
+	if writable {
+		tx.writes = make(map[string]mvcc.Version)
+	}
+
+	return tx, nil
+}
+
+// Get returns the value of the key
+func (db *DB) Get(key []byte) ([]byte, error) {
+	tx, beginErr := db.Begin(false)
+	if beginErr != nil {
+		return nil, beginErr
+	}
+	defer tx.Commit()
+
+	return tx.Get(key)
+}
+
+// Put commits a new version of the key holding the value. Puts of different
+// goroutines only wait for each other when they write the same key or change
+// the same nodes of the tree, a put waits for the transactions holding a
+// lock on the key.
+func (db *DB) Put(key []byte, value []byte) error {
+	if err := db.enter(); err != nil {
+		return err
+	}
+	defer db.leave()
+
+	if len(key) > node.MAX_KEY_SIZE {
+		return ErrKeyTooLarge
+	}
+
+	owner := db.locks.NewOwner()
+	defer db.locks.ReleaseAll(owner)
+	if err := db.locks.Lock(owner, lock.Key(key), lock.Exclusive, db.lockTimeout); err != nil {
+		return err
+	}
+
+	db.writers.RLock()
+	defer db.writers.RUnlock()
+
+	writer := db.pager.NewWriter()
+	versions, readErr := decodeVersions(operations.GetLatest(writer, key))
+	if readErr != nil {
+		return readErr
+	}
+
+	timestamp := db.oracle.Next()
+	defer db.oracle.Finish(timestamp)
+	writer.SetCommitTimestamp(timestamp)
+	version := mvcc.Version{Timestamp: timestamp, Data: value}
+	return db.writeVersion(writer, key, version, versions)
+}
+
+// Delete removes the key, ErrKeyNotFound is returned when it does not exist
+func (db *DB) Delete(key []byte) error {
+	return db.update(func(tx *Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+// Migrate rewrites the nodes stored in an older format in the current format
+// and returns the number of rewritten nodes. Databases in an older format
+// can be used without migrating, their nodes are upgraded when they are read.
+func (db *DB) Migrate() (int, error) {
+	if err := db.enter(); err != nil {
+		return 0, err
+	}
+	defer db.leave()
+
+	db.writers.Lock()
+	defer db.writers.Unlock()
+
+	migrated, migrateErr := operations.Migrate(db.pager)
+	if migrateErr != nil {
+		return 0, fmt.Errorf("failed to migrate database: %w", migrateErr)
+	}
+
+	return migrated, nil
+}
+
+// update runs fn in a writable transaction which is committed when fn
+// returns nil and rolled back otherwise
+func (db *DB) update(fn func(tx *Tx) error) error {
+	tx, beginErr := db.Begin(true)
+	if beginErr != nil {
+		return beginErr
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// commit installs the writes of the transaction as versions of a new commit
+// and ends its snapshot, ErrWriteConflict is returned when a key was
+// committed by another transaction after the transaction began
+func (db *DB) commit(tx *Tx) error {
+	db.writers.Lock()
+	defer db.writers.Unlock()
+
+	// the snapshot ends once no other writer can prune the versions the
+	// transaction conflicts with, the versions only it reads are dropped
+	tx.endSnapshot()
+
+	keys := make([]string, 0, len(tx.writes))
+	for key := range tx.writes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// the snapshots taken during the commit read before its timestamp
+	timestamp := db.oracle.Next()
+	defer db.oracle.Finish(timestamp)
+
+	return db.pager.Atomic(func() error {
+		versions := make([][]mvcc.Version, len(keys))
+		for index, key := range keys {
+			var getErr error
+			if versions[index], getErr = getVersions(db.pager, []byte(key)); getErr != nil {
+				return getErr
+			}
+
+			if mvcc.Conflicts(versions[index], tx.timestamp) {
+				return fmt.Errorf("%w: key %q", ErrWriteConflict, key)
+			}
+		}
+
+		db.pager.SetCommitTimestamp(timestamp)
+		for index, key := range keys {
+			version := tx.writes[key]
+			version.Timestamp = timestamp
+			if err := db.writeVersion(db.pager, []byte(key), version, versions[index]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,492 @@
+package bricker
+
+import (
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBMigrateCurrentFormat(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_migrate.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	for i := uint32(1); i <= 200; i++ {
+		assert.Nil(t, tx.Insert(testKey(i), []byte("value")))
+	}
+	assert.Nil(t, tx.Commit())
+
+	// nodes written by this version are never rewritten
+	migrated, migrateErr := db.Migrate()
+	assert.Nil(t, migrateErr)
+	assert.Zero(t, migrated)
+}
+
+func testValue(i uint32, round int) []byte {
+	return []byte(fmt.Sprintf("value%d-%d", i, round))
+}
+
+// checkValues reads every key in a read only transaction, values is indexed
+// by key and nil for deleted keys
+func checkValues(t *testing.T, db *DB, values [][]byte) {
+	tx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	defer tx.Commit()
+
+	for i, expected := range values {
+		data, getErr := tx.Get(testKey(uint32(i)))
+		if expected == nil {
+			assert.ErrorIs(t, getErr, ErrKeyNotFound)
+		} else {
+			assert.Nil(t, getErr)
+			assert.Equal(t, expected, data)
+		}
+	}
+}
+
+func TestDBReopenAfterManySplits(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_reopen.db"
+	defer os.Remove(dbFileName)
+
+	var values [][]byte
+	for round := 0; round < 4; round++ {
+		db, openErr := Open(dbFileName, nil)
+		assert.Nil(t, openErr)
+		checkValues(t, db, values)
+
+		tx, beginErr := db.Begin(true)
+		assert.Nil(t, beginErr)
+
+		// earlier keys are updated and deleted, new keys are appended
+		for i := range values {
+			key := uint32(i)
+			switch {
+			case values[i] == nil:
+			case key%7 == uint32(round):
+				assert.Nil(t, tx.Delete(testKey(key)))
+				values[i] = nil
+			case key%3 == 0:
+				values[i] = testValue(key, round)
+				assert.Nil(t, tx.Update(testKey(key), values[i]))
+			}
+		}
+		for i := 0; i < 1500; i++ {
+			key := uint32(len(values))
+			values = append(values, testValue(key, round))
+			assert.Nil(t, tx.Insert(testKey(key), values[key]))
+		}
+		assert.Nil(t, tx.Commit())
+
+		checkValues(t, db, values)
+		assert.Nil(t, db.Close())
+	}
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	checkValues(t, db, values)
+}
+
+func TestDBReopenAfterRollback(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_reopen.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	for i := uint32(0); i < 500; i++ {
+		assert.Nil(t, tx.Insert(testKey(i), testValue(i, 0)))
+	}
+	assert.Nil(t, tx.Commit())
+
+	// the rolled back transaction splits the root again
+	tx, beginErr = db.Begin(true)
+	assert.Nil(t, beginErr)
+	for i := uint32(500); i < 3000; i++ {
+		assert.Nil(t, tx.Insert(testKey(i), testValue(i, 0)))
+	}
+	assert.Nil(t, tx.Rollback())
+	assert.Nil(t, db.Close())
+
+	db, openErr = Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	values := make([][]byte, 3000)
+	for i := uint32(0); i < 500; i++ {
+		values[i] = testValue(i, 0)
+	}
+	checkValues(t, db, values)
+}
+
+func TestDBGetPutDelete(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_api.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	_, getErr := db.Get(testKey(1))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+	assert.ErrorIs(t, db.Delete(testKey(1)), ErrKeyNotFound)
+
+	assert.Nil(t, db.Put(testKey(1), []byte("value")))
+	assert.Nil(t, db.Put(testKey(1), []byte("new value")))
+	data, getErr := db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("new value"), data)
+
+	assert.Nil(t, db.Delete(testKey(1)))
+	_, getErr = db.Get(testKey(1))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+
+	assert.ErrorIs(t, db.Put(bytes.Repeat([]byte("k"), 300), []byte("value")), ErrKeyTooLarge)
+}
+
+func TestDBEmptyKeyOnlyLocksItself(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_api.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, &Options{LockTimeout: 10 * time.Millisecond})
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	// the transaction holds the lock of the empty key, the other keys can
+	// still be written
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Upsert(nil, []byte("empty")))
+	assert.Nil(t, db.Put(testKey(1), []byte("value")))
+	assert.ErrorIs(t, db.Put([]byte{}, []byte("other")), ErrLockTimeout)
+	assert.Nil(t, tx.Commit())
+
+	data, getErr := db.Get(nil)
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("empty"), data)
+}
+
+func TestDBClosed(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_api.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	assert.Nil(t, db.Close())
+
+	assert.ErrorIs(t, db.Close(), ErrDatabaseClosed)
+	assert.ErrorIs(t, db.Put(testKey(1), []byte("value")), ErrDatabaseClosed)
+	_, getErr := db.Get(testKey(1))
+	assert.ErrorIs(t, getErr, ErrDatabaseClosed)
+	assert.ErrorIs(t, db.Delete(testKey(1)), ErrDatabaseClosed)
+}
+
+func TestDBCloseWaitsForTransactions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_close.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	assert.Nil(t, db.Put(testKey(1), []byte("value")))
+
+	tx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+
+	closeDone := make(chan error)
+	go func() {
+		closeDone <- db.Close()
+	}()
+
+	for closing := false; !closing; {
+		db.mu.Lock()
+		closing = db.closed
+		db.mu.Unlock()
+	}
+
+	// the goroutine holding the transaction doesn't wait for Close
+	_, getErr := db.Get(testKey(1))
+	assert.ErrorIs(t, getErr, ErrDatabaseClosed)
+	assert.ErrorIs(t, db.Put(testKey(2), []byte("value")), ErrDatabaseClosed)
+
+	data, txGetErr := tx.Get(testKey(1))
+	assert.Nil(t, txGetErr)
+	assert.Equal(t, []byte("value"), data)
+
+	select {
+	case <-closeDone:
+		t.Fatal("close returned before the transaction finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Nil(t, tx.Commit())
+	assert.Nil(t, <-closeDone)
+}
+
+func TestDBOptions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_api.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.PageSize = 3000
+	_, openErr := Open(dbFileName, options)
+	assert.ErrorIs(t, openErr, ErrInvalidPageSize)
+
+	options.PageSize = 16384
+	options.CacheSize = -1
+	_, openErr = Open(dbFileName, options)
+	assert.ErrorIs(t, openErr, ErrInvalidCacheSize)
+
+	options.PageSize = 16384
+	options.CacheSize = 8
+	options.SyncMode = SyncOff
+	db, openErr := Open(dbFileName, options)
+	assert.Nil(t, openErr)
+	for i := uint32(0); i < 2000; i++ {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+	}
+	assert.Nil(t, db.Close())
+
+	db, openErr = Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Equal(t, uint32(16384), db.pager.PageSize())
+	for i := uint32(0); i < 2000; i++ {
+		data, getErr := db.Get(testKey(i))
+		assert.Nil(t, getErr)
+		assert.Equal(t, testValue(i, 0), data)
+	}
+}
+
+func TestDBOptionsDefaultZeroFields(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_zero_options.db"
+	defer os.Remove(dbFileName)
+
+	options := &Options{SyncMode: SyncOff}
+	db, openErr := Open(dbFileName, options)
+	assert.Nil(t, openErr)
+	assert.Equal(t, uint32(pg.DEFAULT_PAGE_SIZE), db.pager.PageSize())
+	assert.Equal(t, DEFAULT_LOCK_TIMEOUT, db.lockTimeout)
+	assert.Equal(t, &Options{SyncMode: SyncOff}, options)
+	assert.Nil(t, db.Put(testKey(1), testValue(1, 0)))
+	assert.Nil(t, db.Close())
+
+	// negative timeouts wait without limit
+	db, openErr = Open(dbFileName, &Options{LockTimeout: -1, VacuumInterval: -1})
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Equal(t, time.Duration(-1), db.lockTimeout)
+
+	holder, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, holder.Upsert(testKey(1), testValue(1, 1)))
+	put := make(chan error)
+	go func() {
+		put <- db.Put(testKey(1), testValue(1, 2))
+	}()
+
+	select {
+	case <-put:
+		t.Fatal("the put did not wait for the lock of the transaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Nil(t, holder.Commit())
+	assert.Nil(t, <-put)
+}
+
+func TestDBConcurrentPuts(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_concurrent.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+
+	// the writers put disjoint ranges of keys while deletes run in writable
+	// transactions, the last key of every range is deleted
+	const writers = 6
+	const keysPerWriter = 300
+	var wg sync.WaitGroup
+	for w := uint32(0); w < writers; w++ {
+		wg.Add(1)
+		go func(start uint32) {
+			defer wg.Done()
+			for i := start; i < start+keysPerWriter; i++ {
+				assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+			}
+			assert.Nil(t, db.Delete(testKey(start+keysPerWriter-1)))
+		}(w * keysPerWriter)
+	}
+	wg.Wait()
+	assert.Nil(t, db.Close())
+
+	values := make([][]byte, writers*keysPerWriter)
+	for i := range values {
+		if i%keysPerWriter != keysPerWriter-1 {
+			values[i] = testValue(uint32(i), 0)
+		}
+	}
+
+	db, openErr = Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	checkValues(t, db, values)
+}
+
+func TestDBSnapshotsWithConcurrentPuts(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_snapshots.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	// every writer puts its keys in order with the value of the round, a
+	// snapshot sees the rounds of a writer decrease along its keys
+	const writers = 4
+	const keysPerWriter = 100
+	const rounds = 10
+	var wg sync.WaitGroup
+	for w := uint32(0); w < writers; w++ {
+		wg.Add(1)
+		go func(start uint32) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				for i := start; i < start+keysPerWriter; i++ {
+					assert.Nil(t, db.Put(testKey(i), []byte(fmt.Sprint(round))))
+				}
+			}
+		}(w * keysPerWriter)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		tx, beginErr := db.Begin(false)
+		assert.Nil(t, beginErr)
+		for w := uint32(0); w < writers; w++ {
+			previous := rounds
+			for i := w * keysPerWriter; i < (w+1)*keysPerWriter; i++ {
+				data, getErr := tx.Get(testKey(i))
+				if getErr != nil {
+					assert.ErrorIs(t, getErr, ErrKeyNotFound)
+					previous = -1
+					continue
+				}
+
+				var round int
+				fmt.Sscan(string(data), &round)
+				assert.LessOrEqual(t, round, previous)
+				previous = round
+			}
+		}
+		assert.Nil(t, tx.Commit())
+	}
+}
+
+func TestDBReadersDoNotWaitForCommits(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_readers_commits.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Nil(t, db.Put(testKey(1), []byte("old")))
+
+	// a commit holds its timestamp until its versions are installed, the
+	// readers beginning meanwhile read before it
+	pending := db.oracle.Next()
+	put := make(chan error)
+	go func() {
+		put <- db.Put(testKey(1), []byte("new"))
+	}()
+
+	tx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	data, getErr := tx.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("old"), data)
+	assert.Nil(t, tx.Commit())
+
+	// the put returns once the older commit finished, its goroutine reads it
+	db.oracle.Finish(pending)
+	assert.Nil(t, <-put)
+	data, getErr = db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("new"), data)
+}
+
+func TestDBConcurrentPutsWithSmallCache(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_small_cache.db"
+	defer os.Remove(dbFileName)
+
+	// the pinned frames of the readers and writers outnumber the cache
+	options := DefaultOptions()
+	options.CacheSize = 1
+	db, openErr := Open(dbFileName, options)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	const writers = 4
+	const keysPerWriter = 200
+	var wg sync.WaitGroup
+	for w := uint32(0); w < writers; w++ {
+		wg.Add(2)
+		go func(start uint32) {
+			defer wg.Done()
+			for i := start; i < start+keysPerWriter; i++ {
+				assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+			}
+		}(w * keysPerWriter)
+		go func() {
+			defer wg.Done()
+			for i := uint32(0); i < keysPerWriter; i++ {
+				_, getErr := db.Get(testKey(i))
+				if getErr != nil {
+					assert.ErrorIs(t, getErr, ErrKeyNotFound)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	values := make([][]byte, writers*keysPerWriter)
+	for i := range values {
+		values[i] = testValue(uint32(i), 0)
+	}
+	checkValues(t, db, values)
+}
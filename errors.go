@@ -0,0 +1,31 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/lock"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"errors"
+)
+
+var ErrKeyNotFound = operations.ErrKeyNotFound
+var ErrKeyExists = errors.New("key already exists")
+var ErrWriteConflict = mvcc.ErrWriteConflict
+var ErrDeadlock = lock.ErrDeadlock
+var ErrLockTimeout = lock.ErrLockTimeout
+var ErrKeyTooLarge = node.ErrKeyTooLarge
+var ErrValueTooLarge = pg.ErrOverflowTooLarge
+var ErrComparatorMismatch = pg.ErrComparatorMismatch
+var ErrInvalidPageSize = pg.ErrInvalidPageSize
+var ErrInvalidCacheSize = errors.New("cache size must not be negative")
+var ErrUnsupportedFileFormat = pg.ErrUnsupportedFileFormat
+var ErrCorruptHeader = pg.ErrCorruptHeader
+var ErrTxClosed = errors.New("transaction is closed")
+var ErrTxNotWritable = errors.New("transaction is read only")
+var ErrBlobClosed = errors.New("blob is closed")
+var ErrDatabaseClosed = errors.New("database is closed")
+
+// ErrCorruptPage is returned when a page read from the file does not match
+// its checksum
+type ErrCorruptPage = pg.ErrCorruptPage
@@ -0,0 +1,24 @@
+package node
+
+import "bytes"
+
+// Comparator defines the order of the keys in a tree. The name is persisted
+// with the database so a file is never opened with a different order.
+type Comparator interface {
+	Compare(a []byte, b []byte) int
+	Name() string
+}
+
+type bytewiseComparator struct{}
+
+func (bytewiseComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+func (bytewiseComparator) Name() string {
+	return "bytewise"
+}
+
+// BytewiseComparator orders keys lexicographically, it is used when no
+// comparator is supplied
+var BytewiseComparator Comparator = bytewiseComparator{}
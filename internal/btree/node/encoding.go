@@ -0,0 +1,105 @@
+package node
+
+import (
+	"bricker-db/internal/utils"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// slots only store the location of the key, the key itself is stored in the
+// data area of the node. Offsets are relative to the node, which is never
+// larger than 64 KiB.
+type keyDataSlot struct {
+	Offset    uint16
+	KeyLength uint16
+	Length    uint32
+}
+
+// the highest bit of the slot length marks overflow entries
+const overflowLengthFlag = 1 << 31
+
+type keyPageSlot struct {
+	Offset    uint16
+	KeyLength uint16
+	PageId    uint32
+}
+
+func EncodeKeyDataRef(key *KeyDataReference) ([]byte, error) {
+	if key.Offset > math.MaxUint16 {
+		return nil, fmt.Errorf("failed to encode key data ref: offset %d is out of the slot range", key.Offset)
+	}
+
+	buf := make([]byte, KEY_DATA_REF_SIZE)
+	writer := utils.NewFixedSizeSliceWriter(buf)
+	length := key.Length
+	if key.Overflow {
+		length |= overflowLengthFlag
+	}
+
+	slot := &keyDataSlot{uint16(key.Offset), uint16(len(key.Key)), length}
+	if err := binary.Write(writer, binary.LittleEndian, slot); err != nil {
+		return nil, fmt.Errorf("failed to encode key data ref: %w", err)
+	}
+
+	return buf, nil
+}
+
+func EncodeKeyPageRef(key *KeyPageReference) ([]byte, error) {
+	if key.Offset > math.MaxUint16 {
+		return nil, fmt.Errorf("failed to encode key page ref: offset %d is out of the slot range", key.Offset)
+	}
+
+	buf := make([]byte, KEY_PAGE_REF_SIZE)
+	writer := utils.NewFixedSizeSliceWriter(buf)
+	slot := &keyPageSlot{uint16(key.Offset), uint16(len(key.Key)), key.PageId}
+	if err := binary.Write(writer, binary.LittleEndian, slot); err != nil {
+		return nil, fmt.Errorf("failed to encode key page ref: %w", err)
+	}
+
+	return buf, nil
+}
+
+// DecodeKeyDataRef decodes the slot and copies the key from the node buffer
+func DecodeKeyDataRef(data []byte, nodeBuf []byte) (*KeyDataReference, error) {
+	slot := &keyDataSlot{}
+	buf := bytes.NewReader(data)
+	if err := binary.Read(buf, binary.LittleEndian, slot); err != nil {
+		return nil, fmt.Errorf("failed to decode key data ref: %w", err)
+	}
+
+	key, keyErr := readKey(nodeBuf, uint32(slot.Offset), uint32(slot.KeyLength))
+	if keyErr != nil {
+		return nil, fmt.Errorf("failed to decode key data ref: %w", keyErr)
+	}
+
+	overflow := slot.Length&overflowLengthFlag != 0
+	return &KeyDataReference{key, uint32(slot.Offset), slot.Length &^ overflowLengthFlag, overflow}, nil
+}
+
+// DecodeKeyPageRef decodes the slot and copies the key from the node buffer
+func DecodeKeyPageRef(data []byte, nodeBuf []byte) (*KeyPageReference, error) {
+	slot := &keyPageSlot{}
+	buf := bytes.NewReader(data)
+	if err := binary.Read(buf, binary.LittleEndian, slot); err != nil {
+		return nil, fmt.Errorf("failed to decode key page ref: %w", err)
+	}
+
+	key, keyErr := readKey(nodeBuf, uint32(slot.Offset), uint32(slot.KeyLength))
+	if keyErr != nil {
+		return nil, fmt.Errorf("failed to decode key page ref: %w", keyErr)
+	}
+
+	return &KeyPageReference{key, uint32(slot.Offset), slot.PageId}, nil
+}
+
+func readKey(nodeBuf []byte, offset uint32, length uint32) ([]byte, error) {
+	if uint64(offset)+uint64(length) > uint64(len(nodeBuf)) {
+		return nil, fmt.Errorf("key at offset %d with length %d is out of the node", offset, length)
+	}
+
+	key := make([]byte, length)
+	copy(key, nodeBuf[offset:(offset+length)])
+	return key, nil
+}
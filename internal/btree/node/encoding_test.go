@@ -0,0 +1,65 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyDataRefEncodingDecoding(t *testing.T) {
+	nodeBuf := []byte("..key")
+	key := &KeyDataReference{
+		[]byte("key"),
+		2,
+		3,
+		false,
+	}
+
+	data, encodingErr := EncodeKeyDataRef(key)
+	assert.NoError(t, encodingErr)
+	assert.Equal(t, KEY_DATA_REF_SIZE, len(data))
+
+	decodedKey, decodingErr := DecodeKeyDataRef(data, nodeBuf)
+	assert.NoError(t, decodingErr)
+	assert.Equal(t, key, decodedKey)
+
+	// the overflow flag does not change the length
+	key.Overflow = true
+	data, encodingErr = EncodeKeyDataRef(key)
+	assert.NoError(t, encodingErr)
+
+	decodedKey, decodingErr = DecodeKeyDataRef(data, nodeBuf)
+	assert.NoError(t, decodingErr)
+	assert.Equal(t, key, decodedKey)
+}
+
+func TestOverflowPointerEncodingDecoding(t *testing.T) {
+	pointer := &OverflowPointer{PageId: 7, Length: 100000}
+
+	decodedPointer, decodingErr := DecodeOverflowPointer(pointer.Encode())
+	assert.NoError(t, decodingErr)
+	assert.Equal(t, pointer, decodedPointer)
+
+	_, shortErr := DecodeOverflowPointer([]byte{1, 2})
+	assert.Error(t, shortErr)
+}
+
+func TestKeyPageRefEncodingDecoding(t *testing.T) {
+	nodeBuf := []byte("....key")
+	key := &KeyPageReference{
+		[]byte("key"),
+		4,
+		7,
+	}
+
+	data, encodingErr := EncodeKeyPageRef(key)
+	assert.NoError(t, encodingErr)
+	assert.Equal(t, KEY_PAGE_REF_SIZE, len(data))
+
+	decodedKey, decodingErr := DecodeKeyPageRef(data, nodeBuf)
+	assert.NoError(t, decodingErr)
+	assert.Equal(t, key, decodedKey)
+
+	_, outOfNodeErr := DecodeKeyPageRef(data, nodeBuf[:5])
+	assert.Error(t, outOfNodeErr)
+}
@@ -1,9 +1,14 @@
 package node
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrNoAvailableSpaceForInsert = errors.New("there is not enough available space for insert")
 var ErrFailedToInsertData = errors.New("failed to insert data")
 var ErrFailedToInsertKeyDataRef = errors.New("failed to insert key data ref")
 var ErrFailedToInsertKeyPageRef = errors.New("failed to insert key page ref")
 var ErrKeyRefAtIndexDoesNotExist = errors.New("Key data reference at given index does not exist")
+var ErrKeyTooLarge = fmt.Errorf("key is larger than %d bytes", MAX_KEY_SIZE)
+var ErrUnsupportedNodeFormat = errors.New("unsupported node format version")
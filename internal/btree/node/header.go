@@ -0,0 +1,87 @@
+package node
+
+import (
+	"bricker-db/internal/utils"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type NodeType uint32
+
+const (
+	InternalNodeType NodeType = iota
+	LeafNodeType
+)
+
+const NODE_HEADER_SIZE = 100
+
+// layout of the slots, nodes written before the version was introduced read
+// as LegacyNodeFormatVersion
+const (
+	LegacyNodeFormatVersion uint32 = iota
+	CompactSlotsNodeFormatVersion
+)
+
+const NODE_FORMAT_VERSION = CompactSlotsNodeFormatVersion
+
+type NodeHeader struct {
+	NodeType NodeType
+	// size of the node stored after the header, it follows from the page size
+	// of the database
+	NodeSize             uint32
+	FreeSpaceStartOffset uint32
+	FreeSpaceEndOffset   uint32
+	ElementsCount        uint32
+	FormatVersion        uint32
+	// bytes of removed entries inside the data area, they are reclaimed when
+	// the node is compacted
+	FragmentedBytes uint32
+}
+
+// GetAvailableSpace returns the contiguous free space between the slots and
+// the data area
+func (h *NodeHeader) GetAvailableSpace() uint32 {
+	return h.FreeSpaceEndOffset - h.FreeSpaceStartOffset
+}
+
+// GetFreeSpace returns the space available after compacting the node
+func (h *NodeHeader) GetFreeSpace() uint32 {
+	return h.GetAvailableSpace() + h.FragmentedBytes
+}
+
+func (h *NodeHeader) GetUsedSpace() uint32 {
+	return h.NodeSize - h.GetFreeSpace()
+}
+
+// IsUnderflow reports whether the node uses less than a quarter of its space,
+// such nodes borrow keys from a sibling or are merged with it
+func (h *NodeHeader) IsUnderflow() bool {
+	return h.isUnderflowAfterRemoving(0)
+}
+
+func (h *NodeHeader) isUnderflowAfterRemoving(size uint32) bool {
+	return h.GetUsedSpace()-size < h.NodeSize/4
+}
+
+func (h *NodeHeader) Encode() ([]byte, error) {
+	buf := make([]byte, NODE_HEADER_SIZE)
+	writer := utils.NewFixedSizeSliceWriter(buf)
+
+	if err := binary.Write(writer, binary.LittleEndian, h); err != nil {
+		return nil, fmt.Errorf("failed to encode node header: %w", err)
+	}
+
+	return buf, nil
+}
+
+func NewNodeHeaderFromBuffer(buf []byte) (*NodeHeader, error) {
+	reader := bytes.NewReader(buf)
+	header := &NodeHeader{}
+
+	if err := binary.Read(reader, binary.LittleEndian, header); err != nil {
+		return nil, fmt.Errorf("failed to decode node header: %w", err)
+	}
+
+	return header, nil
+}
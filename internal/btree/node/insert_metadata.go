@@ -5,12 +5,14 @@ type InsertMetadata struct {
 	HighKey *HighKeyUpdate
 }
 
+// SplitKey is the highest key remaining in the old node, all keys moved to
+// the created node are greater than it
 type SplitMetadata struct {
-	SplitKey    uint32
+	SplitKey    []byte
 	CreatedNode Node
 	OldNode     Node
 }
 
 type HighKeyUpdate struct {
-	NewHighKey uint32
+	NewHighKey []byte
 }
@@ -0,0 +1,468 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/slices"
+)
+
+type InternalNode struct {
+	header *NodeHeader
+	buf    []byte
+}
+
+type InternalNodeInsertResult struct {
+	InsertedKeyPageRef *KeyPageReference
+	Metadata           *InsertMetadata
+}
+
+func NewEmptyInternalNode(size uint32) *InternalNode {
+	buf := make([]byte, size)
+	return &InternalNode{
+		&NodeHeader{
+			InternalNodeType,
+			size,
+			0,
+			size,
+			0,
+			NODE_FORMAT_VERSION,
+			0,
+		},
+		buf,
+	}
+}
+
+func NewInternalNode(header *NodeHeader, data []byte) *InternalNode {
+	return &InternalNode{
+		header,
+		data,
+	}
+}
+
+// Insert adds a key page ref, the node is split when less than MAX_KEY_SIZE
+// bytes would remain available so UpdateAtIndex can always replace a key
+func (i *InternalNode) Insert(key []byte, pageId uint32, comparator Comparator) (*InternalNodeInsertResult, error) {
+	requiredSpace := KEY_PAGE_REF_SIZE + uint32(len(key))
+	if i.header.GetAvailableSpace() < requiredSpace+MAX_KEY_SIZE {
+		// if required space is smaller than half of the node size, we should be able
+		// to insert the data after a split
+		if requiredSpace < (i.header.NodeSize / 2) {
+			return i.splitAndInsert(key, pageId, comparator)
+
+		}
+
+		return nil, ErrNoAvailableSpaceForInsert
+	}
+
+	// find position for the new key
+	exists, index, err := FindPositionForKey(i, key, comparator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find position of key %q: %v", key, err)
+	}
+
+	if exists {
+		return nil, errors.New("insert of existing keys is not supported")
+	}
+
+	var highKeyUpdate *HighKeyUpdate
+	if index == i.GetElementsCount() {
+		highKeyUpdate = &HighKeyUpdate{key}
+	}
+
+	keyRef, insertErr := i.insertToIndex(index, key, pageId)
+	if insertErr != nil {
+		return nil, insertErr
+	}
+
+	return &InternalNodeInsertResult{keyRef, &InsertMetadata{nil, highKeyUpdate}}, nil
+}
+
+// CanAbsorbChildSplit reports whether the split of a child leaves the node
+// unsplit, the split replaces the key of the child and inserts another key
+func (i *InternalNode) CanAbsorbChildSplit() bool {
+	return i.header.GetAvailableSpace() >= KEY_PAGE_REF_SIZE+3*MAX_KEY_SIZE
+}
+
+func (i *InternalNode) UpdateAtIndex(index uint32, key []byte, pageId uint32) (*HighKeyUpdate, error) {
+	if !(index < i.GetElementsCount()) {
+		return nil, errors.New("failed to update key page ref: does not exist")
+	}
+
+	keyPageRef, keyRefErr := i.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return nil, keyRefErr
+	}
+
+	keyPageRef.PageId = pageId
+	if !bytes.Equal(keyPageRef.Key, key) {
+		if i.header.GetAvailableSpace()+uint32(len(keyPageRef.Key)) < uint32(len(key)) {
+			return nil, ErrNoAvailableSpaceForInsert
+		}
+
+		if err := i.removeKeyData(keyPageRef.Offset, uint32(len(keyPageRef.Key))); err != nil {
+			return nil, err
+		}
+
+		keyPageRef.Key = bytes.Clone(key)
+		keyPageRef.Offset = i.header.FreeSpaceEndOffset - uint32(len(key))
+		copy(i.buf[keyPageRef.Offset:], key)
+		i.header.FreeSpaceEndOffset = keyPageRef.Offset
+	}
+
+	keyData, encodingErr := EncodeKeyPageRef(keyPageRef)
+	if encodingErr != nil {
+		return nil, fmt.Errorf("failed to encode key data ref: %v", encodingErr)
+	}
+
+	offsetStart := index * KEY_PAGE_REF_SIZE
+
+	if numOfCopiedBytes := copy(i.buf[offsetStart:(offsetStart+KEY_PAGE_REF_SIZE)], keyData); numOfCopiedBytes != KEY_PAGE_REF_SIZE {
+		return nil, ErrFailedToInsertKeyPageRef
+	}
+
+	// if we are updating the high key
+	if index == (i.GetElementsCount() - 1) {
+		return &HighKeyUpdate{key}, nil
+	}
+
+	return nil, nil
+}
+
+func (i *InternalNode) insertToIndex(index uint32, key []byte, pageId uint32) (*KeyPageReference, error) {
+	keySize := uint32(len(key))
+	if i.header.GetAvailableSpace() < KEY_PAGE_REF_SIZE+keySize {
+		return nil, ErrNoAvailableSpaceForInsert
+	}
+
+	// create key
+	keyPageRef := &KeyPageReference{
+		bytes.Clone(key),
+		i.header.FreeSpaceEndOffset - keySize,
+		pageId,
+	}
+
+	keyData, encodingErr := EncodeKeyPageRef(keyPageRef)
+	if encodingErr != nil {
+		return nil, fmt.Errorf("failed to encode key data ref: %v", encodingErr)
+	}
+
+	// offset existing keys
+	offsetStart := index * KEY_PAGE_REF_SIZE
+	if index < i.header.ElementsCount {
+		offsetEnd := i.header.ElementsCount * KEY_PAGE_REF_SIZE
+		if copiedBytes := copy(i.buf[(offsetStart+KEY_PAGE_REF_SIZE):], i.buf[offsetStart:offsetEnd]); copiedBytes != int(offsetEnd-offsetStart) {
+			return nil, errors.New("failed to shift existing key page refs")
+		}
+	}
+
+	if numOfCopiedBytes := copy(i.buf[offsetStart:(offsetStart+KEY_PAGE_REF_SIZE)], keyData); numOfCopiedBytes != KEY_PAGE_REF_SIZE {
+		return nil, ErrFailedToInsertKeyPageRef
+	}
+
+	copy(i.buf[keyPageRef.Offset:(keyPageRef.Offset+keySize)], key)
+
+	// update header
+	i.header.ElementsCount += 1
+	i.header.FreeSpaceStartOffset += KEY_PAGE_REF_SIZE
+	i.header.FreeSpaceEndOffset -= keySize
+
+	return keyPageRef, nil
+}
+
+func (i *InternalNode) GetElementsCount() uint32 {
+	return i.header.ElementsCount
+}
+
+func (i *InternalNode) GetKeyPageRefByIndex(index uint32) (*KeyPageReference, error) {
+	if !(index < i.GetElementsCount()) {
+		return nil, ErrKeyRefAtIndexDoesNotExist
+	}
+	offset := index * KEY_PAGE_REF_SIZE
+	keyData := i.buf[offset:(offset + KEY_PAGE_REF_SIZE)]
+	return DecodeKeyPageRef(keyData, i.buf)
+}
+
+func (i *InternalNode) GetKeyRefeferenceByIndex(index uint32) (KeyReference, error) {
+	// wrapper to accommodate the node interface signature
+	return i.GetKeyPageRefByIndex(index)
+}
+
+func (i *InternalNode) splitAndInsert(key []byte, pageId uint32, comparator Comparator) (*InternalNodeInsertResult, error) {
+	var keyRefs []*KeyPageReference
+	for index := uint32(0); index < i.header.ElementsCount; index++ {
+		ref, err := i.GetKeyPageRefByIndex(index)
+		if err != nil {
+			return nil, err
+		}
+
+		keyRefs = append(keyRefs, ref)
+	}
+
+	var keyRefsCommit []*KeyPageReferenceCommit
+	for _, keyRef := range keyRefs {
+		keyRefsCommit = append(keyRefsCommit, &KeyPageReferenceCommit{keyRef, true})
+	}
+
+	exist, newItemPosition := FindPositionForKeyInRefs(key, keyRefs, comparator)
+	if exist {
+		return nil, errors.New("cannot insert to an existing position")
+	}
+
+	var highKeyUpdate *HighKeyUpdate
+	if int(newItemPosition) == len(keyRefs) {
+		highKeyUpdate = &HighKeyUpdate{key}
+	}
+
+	newItemKeyRef := &KeyPageReference{bytes.Clone(key), 0, pageId}
+	keyRefsCommit = slices.Insert(keyRefsCommit, int(newItemPosition), &KeyPageReferenceCommit{newItemKeyRef, false})
+
+	splitPoint := int32(math.Ceil(float64(len(keyRefsCommit)) / 2))
+	// split key is the highest key remaining in the old node
+	splitKey := keyRefsCommit[splitPoint-1].keyPageRef.Key
+
+	newNode := NewEmptyInternalNode(i.header.NodeSize)
+	newNodeItems := keyRefsCommit[splitPoint:]
+
+	var insertedKeyRef *KeyPageReference
+	for _, item := range newNodeItems {
+		ref := item.keyPageRef
+		appendedKeyRef, appendErr := newNode.append(ref.Key, ref.PageId)
+		if appendErr != nil {
+			return nil, fmt.Errorf("failed to copy data to new node: %w", appendErr)
+		}
+
+		if !item.committed {
+			insertedKeyRef = appendedKeyRef
+		}
+	}
+
+	// delete moved items in old node, starting from back
+	newNodesLen := len(newNodeItems)
+	for index := newNodesLen - 1; index >= 0; index-- {
+		item := newNodeItems[index]
+		if item.committed {
+			if err := i.deleteLastKeyRef(); err != nil {
+				return nil, fmt.Errorf("failed to delete moved key: %w", err)
+			}
+		}
+	}
+
+	// insert new item to old node if needed
+	if newItemPosition < uint32(splitPoint) {
+		keyRef, insertErr := i.insertToIndex(newItemPosition, key, pageId)
+		if insertErr != nil {
+			return nil, insertErr
+		}
+
+		insertedKeyRef = keyRef
+	}
+
+	return &InternalNodeInsertResult{insertedKeyRef, &InsertMetadata{&SplitMetadata{splitKey, newNode, i}, highKeyUpdate}}, nil
+}
+
+func (i *InternalNode) append(key []byte, pageId uint32) (*KeyPageReference, error) {
+	index := i.header.ElementsCount
+	return i.insertToIndex(index, key, pageId)
+}
+
+func (i *InternalNode) deleteLastKeyRef() error {
+	return i.DeleteAtIndex(i.GetElementsCount() - 1)
+}
+
+// DeleteAtIndex removes the key page ref at the given index and reclaims the
+// space used by its key
+func (i *InternalNode) DeleteAtIndex(index uint32) error {
+	keyRef, keyRefErr := i.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return keyRefErr
+	}
+
+	// shift following keys
+	offsetStart := index * KEY_PAGE_REF_SIZE
+	offsetEnd := i.header.ElementsCount * KEY_PAGE_REF_SIZE
+	copy(i.buf[offsetStart:], i.buf[(offsetStart+KEY_PAGE_REF_SIZE):offsetEnd])
+	clear(i.buf[(offsetEnd - KEY_PAGE_REF_SIZE):offsetEnd])
+
+	i.header.ElementsCount -= 1
+	i.header.FreeSpaceStartOffset -= KEY_PAGE_REF_SIZE
+
+	return i.removeKeyData(keyRef.Offset, uint32(len(keyRef.Key)))
+}
+
+// removeKeyData shifts the keys stored in front of the removed bytes to keep
+// the free space contiguous
+func (i *InternalNode) removeKeyData(offset uint32, length uint32) error {
+	dataStart := i.header.FreeSpaceEndOffset
+	copy(i.buf[(dataStart+length):(offset+length)], i.buf[dataStart:offset])
+	clear(i.buf[dataStart:(dataStart + length)])
+	i.header.FreeSpaceEndOffset += length
+
+	for index := uint32(0); index < i.header.ElementsCount; index++ {
+		keyRef, keyRefErr := i.GetKeyPageRefByIndex(index)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if keyRef.Offset < offset {
+			keyRef.Offset += length
+			if err := i.updateKeyPageRefAtIndex(index, keyRef); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *InternalNode) updateKeyPageRefAtIndex(index uint32, keyPageRef *KeyPageReference) error {
+	keyData, encodingErr := EncodeKeyPageRef(keyPageRef)
+	if encodingErr != nil {
+		return fmt.Errorf("failed to encode key page ref: %v", encodingErr)
+	}
+
+	offset := index * KEY_PAGE_REF_SIZE
+	if numOfCopiedBytes := copy(i.buf[offset:(offset+KEY_PAGE_REF_SIZE)], keyData); numOfCopiedBytes != KEY_PAGE_REF_SIZE {
+		return ErrFailedToInsertKeyPageRef
+	}
+
+	return nil
+}
+
+// CanMerge reports whether all keys of the right sibling fit into the node
+func (i *InternalNode) CanMerge(right *InternalNode) bool {
+	requiredSpace := uint32(0)
+	for index := uint32(0); index < right.GetElementsCount(); index++ {
+		keyRef, keyRefErr := right.GetKeyPageRefByIndex(index)
+		if keyRefErr != nil {
+			return false
+		}
+
+		requiredSpace += KEY_PAGE_REF_SIZE + uint32(len(keyRef.Key))
+	}
+
+	return i.header.GetAvailableSpace() >= requiredSpace
+}
+
+// Merge appends all keys of the right sibling to the node
+func (i *InternalNode) Merge(right *InternalNode) error {
+	for index := uint32(0); index < right.GetElementsCount(); index++ {
+		keyRef, keyRefErr := right.GetKeyPageRefByIndex(index)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if _, err := i.append(keyRef.Key, keyRef.PageId); err != nil {
+			return fmt.Errorf("failed to merge key %q: %w", keyRef.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// BorrowFromLeft moves the highest keys of the left sibling to the node until
+// the node is no longer underflowing or the sibling would underflow
+func (i *InternalNode) BorrowFromLeft(left *InternalNode) error {
+	for i.header.IsUnderflow() && left.GetElementsCount() > 0 {
+		lastIndex := left.GetElementsCount() - 1
+		keyRef, keyRefErr := left.GetKeyPageRefByIndex(lastIndex)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if !i.canBorrow(left, keyRef) {
+			return nil
+		}
+
+		if _, err := i.insertToIndex(0, keyRef.Key, keyRef.PageId); err != nil {
+			return err
+		}
+
+		if err := left.DeleteAtIndex(lastIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BorrowFromRight moves the lowest keys of the right sibling to the node until
+// the node is no longer underflowing or the sibling would underflow
+func (i *InternalNode) BorrowFromRight(right *InternalNode) error {
+	for i.header.IsUnderflow() && right.GetElementsCount() > 0 {
+		keyRef, keyRefErr := right.GetKeyPageRefByIndex(0)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if !i.canBorrow(right, keyRef) {
+			return nil
+		}
+
+		if _, err := i.append(keyRef.Key, keyRef.PageId); err != nil {
+			return err
+		}
+
+		if err := right.DeleteAtIndex(0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *InternalNode) canBorrow(sibling *InternalNode, keyRef *KeyPageReference) bool {
+	requiredSpace := KEY_PAGE_REF_SIZE + uint32(len(keyRef.Key))
+	return i.header.GetAvailableSpace() >= requiredSpace+MAX_KEY_SIZE && !sibling.header.isUnderflowAfterRemoving(requiredSpace)
+}
+
+func (i *InternalNode) GetHeader() *NodeHeader {
+	return i.header
+}
+
+func (i *InternalNode) Clone() Node {
+	header := *i.header
+	buf := make([]byte, len(i.buf))
+	copy(buf, i.buf)
+
+	return &InternalNode{&header, buf}
+}
+
+func (i *InternalNode) GetBuffer() []byte {
+	return i.buf
+}
+
+func (i *InternalNode) FindPositionForKey(key []byte, comparator Comparator) (uint32, *KeyPageReference, error) {
+	_, index, err := FindPositionForKey(i, key, comparator)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if index == i.GetElementsCount() {
+		index = i.GetElementsCount() - 1
+	}
+
+	keyRef, keyRefErr := i.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return 0, nil, keyRefErr
+	}
+
+	return index, keyRef, nil
+}
+
+func (i *InternalNode) GetMaxKey() ([]byte, error) {
+	count := i.GetElementsCount()
+	if !(count > 0) {
+		return nil, errors.New("node does not contain any elements")
+
+	}
+
+	keyRef, keyRefErr := i.GetKeyRefeferenceByIndex(count - 1)
+	if keyRefErr != nil {
+		return nil, keyRefErr
+	}
+
+	return keyRef.GetKey(), nil
+}
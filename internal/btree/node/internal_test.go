@@ -0,0 +1,214 @@
+package node
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertIntoInternalNode(t *testing.T) {
+	nodeSize := uint32(1024)
+	node := NewEmptyInternalNode(nodeSize)
+	key := []byte{1}
+	pageId := uint32(3)
+
+	insertResult, insertErr := node.Insert(key, pageId, BytewiseComparator)
+	keyPageRef := insertResult.InsertedKeyPageRef
+	assert.NoError(t, insertErr)
+	assert.Equal(t, key, keyPageRef.Key)
+	assert.Equal(t, pageId, keyPageRef.PageId)
+	assert.Equal(t, uint32(1), node.GetElementsCount())
+}
+
+func TestInsertBeforeExistingElementIntoInternalNode(t *testing.T) {
+	node := NewEmptyInternalNode(1024)
+
+	key1Page := uint32(5)
+	insert1Result, insertErr := node.Insert([]byte{1}, key1Page, BytewiseComparator)
+	assert.NoError(t, insertErr)
+	key1PageRef := insert1Result.InsertedKeyPageRef
+
+	key2Page := uint32(3)
+	insert2Result, insert2Err := node.Insert([]byte{0}, key2Page, BytewiseComparator)
+	assert.NoError(t, insert2Err)
+	key2PageRef := insert2Result.InsertedKeyPageRef
+
+	// check order
+	firstKeyInNode, getKeyErr := node.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key2PageRef, firstKeyInNode)
+	assert.Equal(t, key2Page, firstKeyInNode.PageId)
+
+	secondKeyInNode, getKeyErr := node.GetKeyPageRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key1PageRef, secondKeyInNode)
+	assert.Equal(t, key1Page, secondKeyInNode.PageId)
+}
+
+func TestInsertAndSplitIntoInternalNode(t *testing.T) {
+	node := NewEmptyInternalNode(280)
+
+	key1 := []byte{2}
+	key1Page := uint32(3)
+	insert1Result, insertErr := node.Insert(key1, key1Page, BytewiseComparator)
+	assert.NoError(t, insertErr)
+	assert.Nil(t, insert1Result.Metadata.Split)
+	key1PageRef := insert1Result.InsertedKeyPageRef
+
+	key2 := []byte{0}
+	key2Page := uint32(5)
+	insert2Result, insert2Err := node.Insert(key2, key2Page, BytewiseComparator)
+	key2PageRef := insert2Result.InsertedKeyPageRef
+	assert.NoError(t, insert2Err)
+	assert.Nil(t, insert2Result.Metadata.Split)
+
+	key3 := []byte{1}
+	key3Page := uint32(7)
+	insert3Result, insert3Err := node.Insert(key3, key3Page, BytewiseComparator)
+	key3PageRef := insert3Result.InsertedKeyPageRef
+	assert.NoError(t, insert3Err)
+	assert.NotNil(t, insert3Result.Metadata.Split)
+
+	// key offsets change when the moved keys are removed from the old node
+	// check old leaf
+	assert.Equal(t, uint32(2), node.GetElementsCount())
+	firstKeyInOldLeaf, getKeyErr := node.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key2PageRef.Key, firstKeyInOldLeaf.Key)
+	assert.Equal(t, key2Page, firstKeyInOldLeaf.PageId)
+
+	secondKeyInOldLeaf, getKeyErr := node.GetKeyPageRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key3PageRef.Key, secondKeyInOldLeaf.Key)
+	assert.Equal(t, key3Page, secondKeyInOldLeaf.PageId)
+
+	_, getKey2Err := node.GetKeyPageRefByIndex(2)
+	assert.ErrorIs(t, ErrKeyRefAtIndexDoesNotExist, getKey2Err)
+
+	// check new leaf
+	newLeaf := insert3Result.Metadata.Split.CreatedNode.(*InternalNode)
+	assert.Equal(t, uint32(1), newLeaf.GetElementsCount())
+	firstKeyInNewLeaf, getKeyInNewLeafErr := newLeaf.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyInNewLeafErr)
+	assert.Equal(t, key1PageRef.Key, firstKeyInNewLeaf.Key)
+	assert.Equal(t, key1Page, firstKeyInNewLeaf.PageId)
+
+	_, getKey2InNewLeafErr := newLeaf.GetKeyPageRefByIndex(1)
+	assert.ErrorIs(t, ErrKeyRefAtIndexDoesNotExist, getKey2InNewLeafErr)
+}
+
+func TestInternalNodeFindPositionForKey(t *testing.T) {
+	node := NewEmptyInternalNode(1024)
+	key1 := []byte{3}
+	_, insertErr := node.Insert(key1, uint32(0), BytewiseComparator)
+	assert.NoError(t, insertErr)
+
+	key2 := []byte{5}
+	_, insert2Err := node.Insert(key2, uint32(1), BytewiseComparator)
+	assert.NoError(t, insert2Err)
+
+	_, position, findErr := node.FindPositionForKey([]byte{1}, BytewiseComparator)
+	assert.NoError(t, findErr)
+	assert.Equal(t, key1, position.Key)
+
+	_, position2, find2Err := node.FindPositionForKey([]byte{7}, BytewiseComparator)
+	assert.NoError(t, find2Err)
+	assert.Equal(t, key2, position2.Key)
+}
+
+func TestInsertAndSplitIntoInternalNodeMovesNewItem(t *testing.T) {
+	node := NewEmptyInternalNode(280)
+
+	_, insert1Err := node.Insert([]byte{0}, uint32(3), BytewiseComparator)
+	assert.NoError(t, insert1Err)
+
+	_, insert2Err := node.Insert([]byte{1}, uint32(5), BytewiseComparator)
+	assert.NoError(t, insert2Err)
+
+	key3 := []byte{2}
+	key3Page := uint32(7)
+	insert3Result, insert3Err := node.Insert(key3, key3Page, BytewiseComparator)
+	assert.NoError(t, insert3Err)
+	assert.NotNil(t, insert3Result.Metadata.Split)
+	assert.Equal(t, []byte{1}, insert3Result.Metadata.Split.SplitKey)
+
+	// new item is moved into the created node together with its page
+	newNode := insert3Result.Metadata.Split.CreatedNode.(*InternalNode)
+	assert.Equal(t, uint32(1), newNode.GetElementsCount())
+	keyPageRef, getKeyErr := newNode.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key3, keyPageRef.Key)
+	assert.Equal(t, key3Page, keyPageRef.PageId)
+	assert.Equal(t, keyPageRef, insert3Result.InsertedKeyPageRef)
+}
+
+func TestDeleteFromInternalNode(t *testing.T) {
+	node := NewEmptyInternalNode(1024)
+	for key := uint32(0); key < 3; key++ {
+		_, insertErr := node.Insert([]byte{byte(key)}, key+10, BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	assert.NoError(t, node.DeleteAtIndex(0))
+	assert.Equal(t, uint32(2), node.GetElementsCount())
+	assert.Equal(t, 2*KEY_PAGE_REF_SIZE, int(node.GetHeader().FreeSpaceStartOffset))
+
+	keyRef, getKeyErr := node.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, []byte{1}, keyRef.Key)
+	assert.Equal(t, uint32(11), keyRef.PageId)
+
+	assert.ErrorIs(t, node.DeleteAtIndex(2), ErrKeyRefAtIndexDoesNotExist)
+}
+
+func TestMergeAndBorrowBetweenInternalNodes(t *testing.T) {
+	// keys are large enough for the borrowed keys to fit next to the room
+	// reserved for a separator update
+	key := func(key uint32) []byte {
+		return bytes.Repeat([]byte{byte(key)}, 32)
+	}
+
+	left := NewEmptyInternalNode(470)
+	for k := uint32(0); k < 5; k++ {
+		_, insertErr := left.Insert(key(k), k+10, BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	right := NewEmptyInternalNode(470)
+	_, insertErr := right.Insert(key(5), uint32(15), BytewiseComparator)
+	assert.NoError(t, insertErr)
+	assert.True(t, right.GetHeader().IsUnderflow())
+
+	assert.NoError(t, right.BorrowFromLeft(left))
+	assert.Equal(t, uint32(3), left.GetElementsCount())
+	assert.Equal(t, uint32(3), right.GetElementsCount())
+
+	keyRef, getKeyErr := right.GetKeyPageRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key(3), keyRef.Key)
+	assert.Equal(t, uint32(13), keyRef.PageId)
+
+	assert.True(t, left.CanMerge(right))
+	assert.NoError(t, left.Merge(right))
+	assert.Equal(t, uint32(6), left.GetElementsCount())
+
+	maxKey, maxKeyErr := left.GetMaxKey()
+	assert.NoError(t, maxKeyErr)
+	assert.Equal(t, key(5), maxKey)
+}
+
+func TestInternalNodeCanAbsorbChildSplit(t *testing.T) {
+	node := NewEmptyInternalNode(1024)
+	assert.True(t, node.CanAbsorbChildSplit())
+
+	for i := byte(1); i <= 3; i++ {
+		_, insertErr := node.Insert(bytes.Repeat([]byte{i}, 100), uint32(i), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	// a split would replace a key and insert another one of up to
+	// MAX_KEY_SIZE bytes each
+	assert.Less(t, node.GetHeader().GetAvailableSpace(), uint32(KEY_PAGE_REF_SIZE+3*MAX_KEY_SIZE))
+	assert.False(t, node.CanAbsorbChildSplit())
+}
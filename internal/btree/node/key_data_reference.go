@@ -0,0 +1,27 @@
+package node
+
+// offset u16, key length u16, data length u32
+const KEY_DATA_REF_SIZE = 8
+
+// KeyDataReference points to an entry in the data area of a leaf, the key is
+// stored at Offset and followed by Length bytes of data. The data of an
+// overflow entry is an OverflowPointer to the pages holding the value.
+type KeyDataReference struct {
+	Key      []byte
+	Offset   uint32
+	Length   uint32
+	Overflow bool
+}
+
+func (k *KeyDataReference) GetKey() []byte {
+	return k.Key
+}
+
+func (k *KeyDataReference) entrySize() uint32 {
+	return uint32(len(k.Key)) + k.Length
+}
+
+type KeyDataReferenceCommit struct {
+	keyDataRef *KeyDataReference
+	committed  bool
+}
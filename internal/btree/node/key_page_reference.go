@@ -0,0 +1,21 @@
+package node
+
+// offset u16, key length u16, page id u32
+const KEY_PAGE_REF_SIZE = 8
+
+// KeyPageReference points to a child page, the key is stored at Offset in
+// the data area of the internal node
+type KeyPageReference struct {
+	Key    []byte
+	Offset uint32
+	PageId uint32
+}
+
+func (k *KeyPageReference) GetKey() []byte {
+	return k.Key
+}
+
+type KeyPageReferenceCommit struct {
+	keyPageRef *KeyPageReference
+	committed  bool
+}
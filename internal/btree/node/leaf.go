@@ -0,0 +1,605 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/exp/slices"
+)
+
+type LeafNode struct {
+	header *NodeHeader
+	buf    []byte
+}
+
+type LeafNodeInsertResult struct {
+	InsertedKeyDataRef *KeyDataReference
+	Metadata           *InsertMetadata
+}
+
+func NewEmptyLeafNode(size uint32) *LeafNode {
+	buf := make([]byte, size)
+	return &LeafNode{
+		&NodeHeader{
+			LeafNodeType,
+			size,
+			0,
+			size,
+			0,
+			NODE_FORMAT_VERSION,
+			0,
+		},
+		buf,
+	}
+}
+
+func NewLeafNode(header *NodeHeader, data []byte) *LeafNode {
+	return &LeafNode{
+		header,
+		data,
+	}
+}
+
+func (l *LeafNode) Insert(key []byte, data []byte, comparator Comparator) (*LeafNodeInsertResult, error) {
+	return l.insert(key, data, false, comparator)
+}
+
+// InsertOverflow adds a key whose value is stored in overflow pages
+func (l *LeafNode) InsertOverflow(key []byte, pointer *OverflowPointer, comparator Comparator) (*LeafNodeInsertResult, error) {
+	return l.insert(key, pointer.Encode(), true, comparator)
+}
+
+func (l *LeafNode) insert(key []byte, data []byte, overflow bool, comparator Comparator) (*LeafNodeInsertResult, error) {
+	entrySize := uint32(len(key) + len(data))
+	requiedSpace := KEY_DATA_REF_SIZE + entrySize
+	if l.header.GetFreeSpace() < requiedSpace {
+		// an entry stored inline fits after a split
+		if requiedSpace <= MaxInlineEntrySize(l.header.NodeSize) {
+			return l.splitAndInsert(key, data, overflow, comparator)
+
+		}
+
+		return nil, ErrNoAvailableSpaceForInsert
+	}
+
+	// find position for the new key
+	exists, index, err := FindPositionForKey(l, key, comparator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find position of key %q: %v", key, err)
+	}
+
+	if exists {
+		return nil, errors.New("insert of existing keys is not supported")
+	}
+
+	var highKeyUpdate *HighKeyUpdate
+	if index == l.GetElementsCount() {
+		highKeyUpdate = &HighKeyUpdate{key}
+	}
+
+	keyRef, insertErr := l.insertToIndex(index, key, data, overflow)
+	if insertErr != nil {
+		return nil, insertErr
+	}
+
+	return &LeafNodeInsertResult{keyRef, &InsertMetadata{nil, highKeyUpdate}}, nil
+}
+
+// WriteEffects tells how storing the data under the key would change the
+// node: whether it splits and whether the key becomes its high key. Overflow
+// tells that the data is stored in overflow pages.
+func (l *LeafNode) WriteEffects(key []byte, data []byte, overflow bool, comparator Comparator) (bool, bool, error) {
+	dataSize := uint32(len(data))
+	if overflow {
+		dataSize = OVERFLOW_POINTER_SIZE
+	}
+
+	exists, index, err := FindPositionForKey(l, key, comparator)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to find position of key %q: %v", key, err)
+	}
+
+	if exists {
+		keyRef, keyRefErr := l.GetKeyDataRefByIndex(index)
+		if keyRefErr != nil {
+			return false, false, keyRefErr
+		}
+
+		splits := dataSize > keyRef.Length && l.header.GetFreeSpace()+keyRef.Length < dataSize
+		return splits, false, nil
+	}
+
+	splits := l.header.GetFreeSpace() < KEY_DATA_REF_SIZE+uint32(len(key))+dataSize
+	return splits, index == l.GetElementsCount(), nil
+}
+
+// UpdateAtIndex replaces the data of an existing key. The data is overwritten
+// in place when it fits into the old entry, reinserted inside the node when
+// it is larger, and the node is split when it has no space left for the data.
+func (l *LeafNode) UpdateAtIndex(index uint32, data []byte, comparator Comparator) (*LeafNodeInsertResult, error) {
+	return l.update(index, data, false, comparator)
+}
+
+// UpdateOverflowAtIndex replaces the data of an existing key with a value
+// stored in overflow pages, the pages of the old value are not freed
+func (l *LeafNode) UpdateOverflowAtIndex(index uint32, pointer *OverflowPointer, comparator Comparator) (*LeafNodeInsertResult, error) {
+	return l.update(index, pointer.Encode(), true, comparator)
+}
+
+func (l *LeafNode) update(index uint32, data []byte, overflow bool, comparator Comparator) (*LeafNodeInsertResult, error) {
+	keyRef, keyRefErr := l.GetKeyDataRefByIndex(index)
+	if keyRefErr != nil {
+		return nil, keyRefErr
+	}
+
+	dataSize := uint32(len(data))
+	if dataSize <= keyRef.Length {
+		return l.overwriteData(index, keyRef, data, overflow)
+	}
+
+	if l.header.GetFreeSpace()+keyRef.Length >= dataSize {
+		return l.reinsertData(index, keyRef, data, overflow)
+	}
+
+	if KEY_DATA_REF_SIZE+uint32(len(keyRef.Key))+dataSize > MaxInlineEntrySize(l.header.NodeSize) {
+		return nil, ErrNoAvailableSpaceForInsert
+	}
+
+	if err := l.DeleteAtIndex(index); err != nil {
+		return nil, err
+	}
+
+	return l.insert(keyRef.Key, data, overflow, comparator)
+}
+
+// overwriteData writes the entry at the end of the old entry and frees the
+// unused bytes in front of it
+func (l *LeafNode) overwriteData(index uint32, keyRef *KeyDataReference, data []byte, overflow bool) (*LeafNodeInsertResult, error) {
+	dataSize := uint32(len(data))
+	unusedOffset := keyRef.Offset
+	unusedLength := keyRef.Length - dataSize
+
+	keyRef.Offset += unusedLength
+	keyRef.Length = dataSize
+	keyRef.Overflow = overflow
+	l.writeEntry(keyRef, data)
+	if err := l.updateKeyDataRefAtIndex(index, keyRef); err != nil {
+		return nil, err
+	}
+
+	l.freeData(unusedOffset, unusedLength)
+
+	return &LeafNodeInsertResult{keyRef, &InsertMetadata{nil, nil}}, nil
+}
+
+// reinsertData removes the old entry and inserts the key with the new data
+// at the same index, the node is compacted when the data only fits into the
+// fragmented space
+func (l *LeafNode) reinsertData(index uint32, keyRef *KeyDataReference, data []byte, overflow bool) (*LeafNodeInsertResult, error) {
+	if err := l.DeleteAtIndex(index); err != nil {
+		return nil, err
+	}
+
+	newKeyRef, insertErr := l.insertToIndex(index, keyRef.Key, data, overflow)
+	if insertErr != nil {
+		return nil, insertErr
+	}
+
+	return &LeafNodeInsertResult{newKeyRef, &InsertMetadata{nil, nil}}, nil
+}
+
+// writeEntry copies the key followed by the data to the offset of the ref
+func (l *LeafNode) writeEntry(keyRef *KeyDataReference, data []byte) {
+	keyEnd := keyRef.Offset + uint32(len(keyRef.Key))
+	copy(l.buf[keyRef.Offset:keyEnd], keyRef.Key)
+	copy(l.buf[keyEnd:(keyEnd+keyRef.Length)], data)
+}
+
+// insertToIndex compacts the node when the entry only fits into the
+// fragmented space
+func (l *LeafNode) insertToIndex(index uint32, key []byte, data []byte, overflow bool) (*KeyDataReference, error) {
+	entrySize := uint32(len(key) + len(data))
+	requiedSpace := KEY_DATA_REF_SIZE + entrySize
+	if l.header.GetFreeSpace() < requiedSpace {
+		return nil, ErrNoAvailableSpaceForInsert
+	}
+
+	if l.header.GetAvailableSpace() < requiedSpace {
+		if err := l.Compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	startDataOffset := l.header.FreeSpaceEndOffset - entrySize
+
+	// create key
+	keyDataRef := &KeyDataReference{
+		bytes.Clone(key),
+		startDataOffset,
+		uint32(len(data)),
+		overflow,
+	}
+
+	keyData, encodingErr := EncodeKeyDataRef(keyDataRef)
+	if encodingErr != nil {
+		return nil, fmt.Errorf("failed to encode key data ref: %v", encodingErr)
+	}
+
+	// offset existing keys
+	if index < l.header.ElementsCount {
+		offsetStart := index * KEY_DATA_REF_SIZE
+		offsetEnd := l.header.ElementsCount * KEY_DATA_REF_SIZE
+		if copiedBytes := copy(l.buf[(offsetStart+KEY_DATA_REF_SIZE):], l.buf[offsetStart:offsetEnd]); copiedBytes != int(offsetEnd-offsetStart) {
+			return nil, errors.New("failed to shift existing keys")
+
+		}
+	}
+
+	keyDataOffset := index * KEY_DATA_REF_SIZE
+	if numOfCopiedBytes := copy(l.buf[keyDataOffset:(keyDataOffset+KEY_DATA_REF_SIZE)], keyData); numOfCopiedBytes != KEY_DATA_REF_SIZE {
+		return nil, ErrFailedToInsertKeyDataRef
+	}
+
+	// copy over buffer
+	l.writeEntry(keyDataRef, data)
+
+	// update header
+	l.header.ElementsCount += 1
+	l.header.FreeSpaceStartOffset += KEY_DATA_REF_SIZE
+	l.header.FreeSpaceEndOffset -= entrySize
+
+	return keyDataRef, nil
+}
+
+func (l *LeafNode) append(key []byte, data []byte, overflow bool) (*KeyDataReference, error) {
+	index := l.header.ElementsCount
+	return l.insertToIndex(index, key, data, overflow)
+}
+
+func (l *LeafNode) splitAndInsert(key []byte, data []byte, overflow bool, comparator Comparator) (*LeafNodeInsertResult, error) {
+	var keyRefs []*KeyDataReference
+	for i := uint32(0); i < l.header.ElementsCount; i++ {
+		ref, err := l.GetKeyDataRefByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+
+		keyRefs = append(keyRefs, ref)
+	}
+
+	var keyRefsCommit []*KeyDataReferenceCommit
+	for _, keyRef := range keyRefs {
+		keyRefsCommit = append(keyRefsCommit, &KeyDataReferenceCommit{keyRef, true})
+	}
+
+	exist, newItemPosition := FindPositionForKeyInRefs(key, keyRefs, comparator)
+	if exist {
+		return nil, errors.New("cannot insert to an existing position")
+	}
+
+	var highKeyUpdate *HighKeyUpdate
+	if int(newItemPosition) == len(keyRefs) {
+		highKeyUpdate = &HighKeyUpdate{key}
+	}
+
+	newItemKeyRef := &KeyDataReference{bytes.Clone(key), 0, 0, overflow}
+	keyRefsCommit = slices.Insert(keyRefsCommit, int(newItemPosition), &KeyDataReferenceCommit{newItemKeyRef, false})
+
+	entrySizes := make([]uint32, len(keyRefsCommit))
+	for index, item := range keyRefsCommit {
+		if item.committed {
+			entrySizes[index] = KEY_DATA_REF_SIZE + item.keyDataRef.entrySize()
+		} else {
+			entrySizes[index] = KEY_DATA_REF_SIZE + uint32(len(key)+len(data))
+		}
+	}
+
+	splitPoint, splitErr := findSplitPoint(entrySizes, l.header.NodeSize)
+	if splitErr != nil {
+		return nil, splitErr
+	}
+
+	// split key is the highest key remaining in the old node
+	splitKey := keyRefsCommit[splitPoint-1].keyDataRef.Key
+
+	newNode := NewEmptyLeafNode(l.header.NodeSize)
+	newNodeItems := keyRefsCommit[splitPoint:]
+
+	var insertedKeyRef *KeyDataReference
+	for _, item := range newNodeItems {
+		ref := item.keyDataRef
+		var itemData []byte
+		if item.committed {
+			itemData = l.GetKeyRefData(ref)
+		} else {
+			itemData = data
+		}
+
+		appendedKeyRef, appendErr := newNode.append(item.keyDataRef.Key, itemData, item.keyDataRef.Overflow)
+		if appendErr != nil {
+			return nil, fmt.Errorf("failed to copy data to new node: %w", appendErr)
+		}
+
+		if !item.committed {
+			insertedKeyRef = appendedKeyRef
+		}
+	}
+
+	// delete moved items in old node, starting from back
+	newNodesLen := len(newNodeItems)
+	for i := newNodesLen - 1; i >= 0; i-- {
+		item := newNodeItems[i]
+		if item.committed {
+			if err := l.deleteLastKeyRef(); err != nil {
+				return nil, fmt.Errorf("failed to delete moved key: %w", err)
+			}
+		}
+	}
+
+	// insert new item to old node if needed
+	if newItemPosition < uint32(splitPoint) {
+		keyRef, insertErr := l.insertToIndex(newItemPosition, key, data, overflow)
+		if insertErr != nil {
+			return nil, insertErr
+		}
+
+		insertedKeyRef = keyRef
+	}
+
+	return &LeafNodeInsertResult{insertedKeyRef, &InsertMetadata{&SplitMetadata{splitKey, newNode, l}, highKeyUpdate}}, nil
+}
+
+// findSplitPoint returns the index of the first entry moved to the new node.
+// Entries have variable sizes, so the point balances the bytes of both halves
+// rather than their number of entries.
+func findSplitPoint(entrySizes []uint32, nodeSize uint32) (int, error) {
+	total := uint32(0)
+	for _, size := range entrySizes {
+		total += size
+	}
+
+	splitPoint := 0
+	largerHalf := uint32(math.MaxUint32)
+	left := uint32(0)
+	for index := 1; index < len(entrySizes); index++ {
+		left += entrySizes[index-1]
+		// on a tie the old node keeps more entries, like a split by count
+		if half := max(left, total-left); half <= largerHalf {
+			splitPoint = index
+			largerHalf = half
+		}
+	}
+
+	if splitPoint == 0 || largerHalf > nodeSize {
+		return 0, ErrNoAvailableSpaceForInsert
+	}
+
+	return splitPoint, nil
+}
+
+func (l *LeafNode) GetKeyDataRefByIndex(index uint32) (*KeyDataReference, error) {
+	if !(index < l.GetElementsCount()) {
+		return nil, ErrKeyRefAtIndexDoesNotExist
+	}
+	offset := index * KEY_DATA_REF_SIZE
+	keyData := l.buf[offset:(offset + KEY_DATA_REF_SIZE)]
+	return DecodeKeyDataRef(keyData, l.buf)
+}
+
+func (l *LeafNode) GetKeyRefeferenceByIndex(index uint32) (KeyReference, error) {
+	// wrapper to accommodate the node interface signature
+	return l.GetKeyDataRefByIndex(index)
+}
+
+func (l *LeafNode) deleteLastKeyRef() error {
+	return l.DeleteAtIndex(l.GetElementsCount() - 1)
+}
+
+// DeleteAtIndex removes the key data ref at the given index, the space used
+// by its entry is reclaimed when the node is compacted
+func (l *LeafNode) DeleteAtIndex(index uint32) error {
+	keyRef, keyRefErr := l.GetKeyDataRefByIndex(index)
+	if keyRefErr != nil {
+		return keyRefErr
+	}
+
+	// shift following keys
+	offsetStart := index * KEY_DATA_REF_SIZE
+	offsetEnd := l.header.ElementsCount * KEY_DATA_REF_SIZE
+	copy(l.buf[offsetStart:], l.buf[(offsetStart+KEY_DATA_REF_SIZE):offsetEnd])
+	clear(l.buf[(offsetEnd - KEY_DATA_REF_SIZE):offsetEnd])
+
+	l.header.ElementsCount -= 1
+	l.header.FreeSpaceStartOffset -= KEY_DATA_REF_SIZE
+
+	l.freeData(keyRef.Offset, keyRef.entrySize())
+
+	return nil
+}
+
+// freeData releases the bytes of a removed entry, they extend the free
+// space when they are next to it and are fragmented otherwise
+func (l *LeafNode) freeData(offset uint32, length uint32) {
+	clear(l.buf[offset:(offset + length)])
+	if offset == l.header.FreeSpaceEndOffset {
+		l.header.FreeSpaceEndOffset += length
+		return
+	}
+
+	l.header.FragmentedBytes += length
+}
+
+// Compact rewrites the live entries at the end of the node so the fragmented
+// bytes become part of the contiguous free space
+func (l *LeafNode) Compact() error {
+	if l.header.FragmentedBytes == 0 {
+		return nil
+	}
+
+	data := make([]byte, l.header.NodeSize)
+	end := l.header.NodeSize
+	for index := uint32(0); index < l.header.ElementsCount; index++ {
+		keyRef, keyRefErr := l.GetKeyDataRefByIndex(index)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		entrySize := keyRef.entrySize()
+		end -= entrySize
+		copy(data[end:], l.buf[keyRef.Offset:(keyRef.Offset+entrySize)])
+
+		keyRef.Offset = end
+		if err := l.updateKeyDataRefAtIndex(index, keyRef); err != nil {
+			return err
+		}
+	}
+
+	copy(l.buf[l.header.FreeSpaceStartOffset:], data[l.header.FreeSpaceStartOffset:])
+	l.header.FreeSpaceEndOffset = end
+	l.header.FragmentedBytes = 0
+
+	return nil
+}
+
+func (l *LeafNode) updateKeyDataRefAtIndex(index uint32, keyDataRef *KeyDataReference) error {
+	keyData, encodingErr := EncodeKeyDataRef(keyDataRef)
+	if encodingErr != nil {
+		return fmt.Errorf("failed to encode key data ref: %v", encodingErr)
+	}
+
+	offset := index * KEY_DATA_REF_SIZE
+	if numOfCopiedBytes := copy(l.buf[offset:(offset+KEY_DATA_REF_SIZE)], keyData); numOfCopiedBytes != KEY_DATA_REF_SIZE {
+		return ErrFailedToInsertKeyDataRef
+	}
+
+	return nil
+}
+
+// CanMerge reports whether all keys of the right sibling fit into the node
+func (l *LeafNode) CanMerge(right *LeafNode) bool {
+	requiredSpace := uint32(0)
+	for index := uint32(0); index < right.GetElementsCount(); index++ {
+		keyRef, keyRefErr := right.GetKeyDataRefByIndex(index)
+		if keyRefErr != nil {
+			return false
+		}
+
+		requiredSpace += KEY_DATA_REF_SIZE + keyRef.entrySize()
+	}
+
+	return l.header.GetFreeSpace() >= requiredSpace
+}
+
+// Merge appends all keys of the right sibling to the node
+func (l *LeafNode) Merge(right *LeafNode) error {
+	for index := uint32(0); index < right.GetElementsCount(); index++ {
+		keyRef, keyRefErr := right.GetKeyDataRefByIndex(index)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if _, err := l.append(keyRef.Key, right.GetKeyRefData(keyRef), keyRef.Overflow); err != nil {
+			return fmt.Errorf("failed to merge key %q: %w", keyRef.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// BorrowFromLeft moves the highest keys of the left sibling to the node until
+// the node is no longer underflowing or the sibling would underflow
+func (l *LeafNode) BorrowFromLeft(left *LeafNode) error {
+	for l.header.IsUnderflow() && left.GetElementsCount() > 0 {
+		lastIndex := left.GetElementsCount() - 1
+		keyRef, keyRefErr := left.GetKeyDataRefByIndex(lastIndex)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if !l.canBorrow(left, keyRef) {
+			return nil
+		}
+
+		if _, err := l.insertToIndex(0, keyRef.Key, left.GetKeyRefData(keyRef), keyRef.Overflow); err != nil {
+			return err
+		}
+
+		if err := left.DeleteAtIndex(lastIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BorrowFromRight moves the lowest keys of the right sibling to the node until
+// the node is no longer underflowing or the sibling would underflow
+func (l *LeafNode) BorrowFromRight(right *LeafNode) error {
+	for l.header.IsUnderflow() && right.GetElementsCount() > 0 {
+		keyRef, keyRefErr := right.GetKeyDataRefByIndex(0)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		if !l.canBorrow(right, keyRef) {
+			return nil
+		}
+
+		if _, err := l.append(keyRef.Key, right.GetKeyRefData(keyRef), keyRef.Overflow); err != nil {
+			return err
+		}
+
+		if err := right.DeleteAtIndex(0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *LeafNode) canBorrow(sibling *LeafNode, keyRef *KeyDataReference) bool {
+	requiredSpace := KEY_DATA_REF_SIZE + keyRef.entrySize()
+	return l.header.GetFreeSpace() >= requiredSpace && !sibling.header.isUnderflowAfterRemoving(requiredSpace)
+}
+
+func (l *LeafNode) GetElementsCount() uint32 {
+	return l.header.ElementsCount
+}
+
+func (l *LeafNode) GetKeyRefData(keyDataRef *KeyDataReference) []byte {
+	dataStart := keyDataRef.Offset + uint32(len(keyDataRef.Key))
+	return l.buf[dataStart:(dataStart + keyDataRef.Length)]
+}
+
+func (l *LeafNode) GetHeader() *NodeHeader {
+	return l.header
+}
+
+func (l *LeafNode) Clone() Node {
+	header := *l.header
+	buf := make([]byte, len(l.buf))
+	copy(buf, l.buf)
+
+	return &LeafNode{&header, buf}
+}
+
+func (l *LeafNode) GetBuffer() []byte {
+	return l.buf
+}
+
+func (l *LeafNode) GetMaxKey() ([]byte, error) {
+	count := l.GetElementsCount()
+	if !(count > 0) {
+		return nil, errors.New("node does not contain any elements")
+
+	}
+
+	keyRef, keyRefErr := l.GetKeyRefeferenceByIndex(count - 1)
+	if keyRefErr != nil {
+		return nil, keyRefErr
+	}
+
+	return keyRef.GetKey(), nil
+}
@@ -0,0 +1,453 @@
+package node
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertIntoLeafNode(t *testing.T) {
+	leafSize := uint32(1024)
+	leaf := NewEmptyLeafNode(leafSize)
+	data := []byte("data")
+	key := []byte{1}
+
+	insertResult, insertErr := leaf.Insert(key, data, BytewiseComparator)
+	keyDataRef := insertResult.InsertedKeyDataRef
+	assert.NoError(t, insertErr)
+	assert.Equal(t, key, keyDataRef.Key)
+	assert.Equal(t, uint32(len(data)), keyDataRef.Length)
+	assert.Equal(t, leafSize-uint32(len(key)+len(data)), keyDataRef.Offset)
+
+	assert.Equal(t, uint32(1), leaf.GetElementsCount())
+}
+
+func TestInsertBeforeExistingElementIntoLeafNode(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+
+	key1Data := []byte("key1Data")
+	insert1Result, insertErr := leaf.Insert([]byte{1}, key1Data, BytewiseComparator)
+	assert.NoError(t, insertErr)
+	key1DataRef := insert1Result.InsertedKeyDataRef
+
+	key2Data := []byte("key2Data")
+	insert2Result, insert2Err := leaf.Insert([]byte{0}, key2Data, BytewiseComparator)
+	assert.NoError(t, insert2Err)
+	key2DataRef := insert2Result.InsertedKeyDataRef
+
+	// check order
+	firstKeyInLeaf, getKeyErr := leaf.GetKeyDataRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key2DataRef, firstKeyInLeaf)
+
+	secondKeyInLeaf, getKeyErr := leaf.GetKeyDataRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key1DataRef, secondKeyInLeaf)
+
+	// check data
+	firstKeyData := leaf.GetKeyRefData(firstKeyInLeaf)
+	assert.Equal(t, key2Data, firstKeyData)
+	secondKeyData := leaf.GetKeyRefData(secondKeyInLeaf)
+	assert.Equal(t, key1Data, secondKeyData)
+}
+
+func TestInsertAndSplit(t *testing.T) {
+	// entries of 14 bytes stay below a third of the node, the fourth one
+	// splits it
+	leaf := NewEmptyLeafNode(45)
+
+	key1Data := []byte("data1")
+	insert1Result, insertErr := leaf.Insert([]byte{2}, key1Data, BytewiseComparator)
+	assert.NoError(t, insertErr)
+	assert.Nil(t, insert1Result.Metadata.Split)
+	key1DataRef := insert1Result.InsertedKeyDataRef
+
+	key2Data := []byte("data2")
+	insert2Result, insert2Err := leaf.Insert([]byte{0}, key2Data, BytewiseComparator)
+	assert.NoError(t, insert2Err)
+	assert.Nil(t, insert2Result.Metadata.Split)
+	key2DataRef := insert2Result.InsertedKeyDataRef
+
+	key4Data := []byte("data4")
+	insert4Result, insert4Err := leaf.Insert([]byte{3}, key4Data, BytewiseComparator)
+	assert.NoError(t, insert4Err)
+	assert.Nil(t, insert4Result.Metadata.Split)
+	key4DataRef := insert4Result.InsertedKeyDataRef
+
+	key3Data := []byte("data3")
+	insert3Result, insert3Err := leaf.Insert([]byte{1}, key3Data, BytewiseComparator)
+	assert.NoError(t, insert3Err)
+	assert.NotNil(t, insert3Result.Metadata.Split)
+	key3DataRef := insert3Result.InsertedKeyDataRef
+	assert.Equal(t, key3DataRef.Key, insert3Result.Metadata.Split.SplitKey)
+
+	// entry offsets change when the moved entries are removed from the old leaf
+	// check old leaf
+	assert.Equal(t, uint32(2), leaf.GetElementsCount())
+	firstKeyInOldLeaf, getKeyErr := leaf.GetKeyDataRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key2DataRef.Key, firstKeyInOldLeaf.Key)
+	assert.Equal(t, []byte{0}, key2DataRef.Key)
+	firstKeyInOldLeafData := leaf.GetKeyRefData(firstKeyInOldLeaf)
+	assert.Equal(t, key2Data, firstKeyInOldLeafData)
+
+	secondKeyInOldLeaf, getKeyErr := leaf.GetKeyDataRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, key3DataRef, secondKeyInOldLeaf)
+	secondKeyInOldLeafData := leaf.GetKeyRefData(secondKeyInOldLeaf)
+	assert.Equal(t, key3Data, secondKeyInOldLeafData)
+
+	_, getKey3Err := leaf.GetKeyDataRefByIndex(2)
+	assert.ErrorIs(t, ErrKeyRefAtIndexDoesNotExist, getKey3Err)
+
+	// check new leaf
+	newLeaf := insert3Result.Metadata.Split.CreatedNode.(*LeafNode)
+	assert.Equal(t, uint32(2), newLeaf.GetElementsCount())
+	firstKeyInNewLeaf, getKeyInNewLeafErr := newLeaf.GetKeyDataRefByIndex(0)
+	assert.NoError(t, getKeyInNewLeafErr)
+	assert.Equal(t, key1DataRef.Key, firstKeyInNewLeaf.Key)
+	firstKeyInNewLeafData := newLeaf.GetKeyRefData(firstKeyInNewLeaf)
+	assert.Equal(t, key1Data, firstKeyInNewLeafData)
+
+	secondKeyInNewLeaf, getKeyInNewLeafErr := newLeaf.GetKeyDataRefByIndex(1)
+	assert.NoError(t, getKeyInNewLeafErr)
+	assert.Equal(t, key4DataRef.Key, secondKeyInNewLeaf.Key)
+	secondKeyInNewLeafData := newLeaf.GetKeyRefData(secondKeyInNewLeaf)
+	assert.Equal(t, key4Data, secondKeyInNewLeafData)
+
+	_, getKey2InNewLeafErr := newLeaf.GetKeyDataRefByIndex(2)
+	assert.ErrorIs(t, ErrKeyRefAtIndexDoesNotExist, getKey2InNewLeafErr)
+}
+
+func TestInsertRejectsEntryLargerThanInlineSize(t *testing.T) {
+	leaf := NewEmptyLeafNode(45)
+	assert.Equal(t, uint32(14), MaxInlineEntrySize(45))
+
+	_, insertErr := leaf.Insert([]byte{1}, []byte("data1"), BytewiseComparator)
+	assert.NoError(t, insertErr)
+	_, insertErr = leaf.Insert([]byte{2}, []byte("data2"), BytewiseComparator)
+	assert.NoError(t, insertErr)
+
+	// the entry would fit in the free space but not after a split of a full
+	// node
+	_, insertErr = leaf.Insert([]byte{3}, []byte("data3+"), BytewiseComparator)
+	assert.NoError(t, insertErr)
+	_, insertErr = leaf.Insert([]byte{4}, []byte("data4+"), BytewiseComparator)
+	assert.ErrorIs(t, insertErr, ErrNoAvailableSpaceForInsert)
+}
+
+func TestSplitLeafBalancesBytes(t *testing.T) {
+	leaf := NewEmptyLeafNode(1000)
+	entries := map[byte][]byte{}
+	for key := byte(0); key < 10; key++ {
+		entries[key] = bytes.Repeat([]byte{key}, 10)
+	}
+	for key := byte(20); key < 23; key++ {
+		entries[key] = bytes.Repeat([]byte{key}, 320)
+	}
+
+	// a split by count would move the three large entries into the new node
+	var split *SplitMetadata
+	for key := byte(0); key < 23; key++ {
+		data, ok := entries[key]
+		if !ok {
+			continue
+		}
+
+		insertResult, insertErr := leaf.Insert([]byte{key}, data, BytewiseComparator)
+		assert.NoError(t, insertErr)
+		if insertResult.Metadata.Split != nil {
+			split = insertResult.Metadata.Split
+		}
+	}
+	assert.NotNil(t, split)
+
+	found := 0
+	for _, node := range []*LeafNode{leaf, split.CreatedNode.(*LeafNode)} {
+		assert.NotZero(t, node.GetElementsCount())
+		for index := uint32(0); index < node.GetElementsCount(); index++ {
+			keyRef, getKeyErr := node.GetKeyDataRefByIndex(index)
+			assert.NoError(t, getKeyErr)
+			assert.Equal(t, entries[keyRef.Key[0]], node.GetKeyRefData(keyRef))
+			found++
+		}
+	}
+	assert.Equal(t, len(entries), found)
+}
+
+func TestDeleteFromLeafNodeReclaimsSpace(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	emptyFreeSpace := leaf.GetHeader().GetFreeSpace()
+
+	for key, data := range []string{"key0Data", "key1LongerData", "key2"} {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte(data), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	deleteErr := leaf.DeleteAtIndex(1)
+	assert.NoError(t, deleteErr)
+	assert.Equal(t, uint32(2), leaf.GetElementsCount())
+	assert.Equal(t, emptyFreeSpace-2*KEY_DATA_REF_SIZE-uint32(2+len("key0Data")+len("key2")), leaf.GetHeader().GetFreeSpace())
+
+	firstKeyRef, getKeyErr := leaf.GetKeyDataRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, []byte{0}, firstKeyRef.Key)
+	assert.Equal(t, []byte("key0Data"), leaf.GetKeyRefData(firstKeyRef))
+
+	secondKeyRef, getKeyErr := leaf.GetKeyDataRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, []byte{2}, secondKeyRef.Key)
+	assert.Equal(t, []byte("key2"), leaf.GetKeyRefData(secondKeyRef))
+
+	assert.NoError(t, leaf.DeleteAtIndex(1))
+	assert.NoError(t, leaf.DeleteAtIndex(0))
+	assert.Equal(t, emptyFreeSpace, leaf.GetHeader().GetFreeSpace())
+
+	_, getKeyErr = leaf.GetKeyDataRefByIndex(0)
+	assert.ErrorIs(t, getKeyErr, ErrKeyRefAtIndexDoesNotExist)
+}
+
+func TestMergeLeafNodes(t *testing.T) {
+	left := NewEmptyLeafNode(1024)
+	_, insertErr := left.Insert([]byte{0}, []byte("key0Data"), BytewiseComparator)
+	assert.NoError(t, insertErr)
+
+	right := NewEmptyLeafNode(1024)
+	_, insert2Err := right.Insert([]byte{1}, []byte("key1Data"), BytewiseComparator)
+	assert.NoError(t, insert2Err)
+
+	assert.True(t, left.CanMerge(right))
+	assert.NoError(t, left.Merge(right))
+	assert.Equal(t, uint32(2), left.GetElementsCount())
+
+	keyRef, getKeyErr := left.GetKeyDataRefByIndex(1)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, []byte{1}, keyRef.Key)
+	assert.Equal(t, []byte("key1Data"), left.GetKeyRefData(keyRef))
+}
+
+func TestBorrowBetweenLeafNodes(t *testing.T) {
+	left := NewEmptyLeafNode(128)
+	for key := uint32(0); key < 6; key++ {
+		_, insertErr := left.Insert([]byte{byte(key)}, []byte("data"), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	right := NewEmptyLeafNode(128)
+	_, insertErr := right.Insert([]byte{6}, []byte("data"), BytewiseComparator)
+	assert.NoError(t, insertErr)
+	assert.True(t, right.GetHeader().IsUnderflow())
+
+	assert.NoError(t, right.BorrowFromLeft(left))
+	assert.False(t, right.GetHeader().IsUnderflow())
+	assert.False(t, left.GetHeader().IsUnderflow())
+	assert.Equal(t, uint32(4), left.GetElementsCount())
+	assert.Equal(t, uint32(3), right.GetElementsCount())
+
+	firstKeyRef, getKeyErr := right.GetKeyDataRefByIndex(0)
+	assert.NoError(t, getKeyErr)
+	assert.Equal(t, []byte{4}, firstKeyRef.Key)
+
+	// borrowing back moves the lowest keys of the right node
+	for left.GetElementsCount() > 1 {
+		assert.NoError(t, left.DeleteAtIndex(0))
+	}
+	for key := uint32(7); key < 10; key++ {
+		_, insertErr := right.Insert([]byte{byte(key)}, []byte("data"), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+	assert.NoError(t, left.BorrowFromRight(right))
+	assert.Equal(t, uint32(3), left.GetElementsCount())
+	assert.Equal(t, uint32(4), right.GetElementsCount())
+
+	maxKey, maxKeyErr := left.GetMaxKey()
+	assert.NoError(t, maxKeyErr)
+	assert.Equal(t, []byte{5}, maxKey)
+}
+
+func TestUpdateLeafNodeInPlace(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	for key, data := range []string{"key0Data", "key1Data", "key2Data"} {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte(data), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+	freeSpace := leaf.GetHeader().GetFreeSpace()
+
+	updateResult, updateErr := leaf.UpdateAtIndex(1, []byte("new"), BytewiseComparator)
+	assert.NoError(t, updateErr)
+	assert.Nil(t, updateResult.Metadata.Split)
+	assert.Equal(t, freeSpace+uint32(len("key1Data")-len("new")), leaf.GetHeader().GetFreeSpace())
+
+	for index, data := range []string{"key0Data", "new", "key2Data"} {
+		keyRef, getKeyErr := leaf.GetKeyDataRefByIndex(uint32(index))
+		assert.NoError(t, getKeyErr)
+		assert.Equal(t, []byte{byte(index)}, keyRef.Key)
+		assert.Equal(t, []byte(data), leaf.GetKeyRefData(keyRef))
+	}
+}
+
+func TestUpdateLeafNodeRelocatesLargerData(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	for key, data := range []string{"key0Data", "key1Data", "key2Data"} {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte(data), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+	freeSpace := leaf.GetHeader().GetFreeSpace()
+
+	updateResult, updateErr := leaf.UpdateAtIndex(0, []byte("key0LongerData"), BytewiseComparator)
+	assert.NoError(t, updateErr)
+	assert.Nil(t, updateResult.Metadata.Split)
+	assert.Equal(t, freeSpace-uint32(len("key0LongerData")-len("key0Data")), leaf.GetHeader().GetFreeSpace())
+	assert.Equal(t, leaf.GetHeader().FreeSpaceEndOffset, updateResult.InsertedKeyDataRef.Offset)
+
+	for index, data := range []string{"key0LongerData", "key1Data", "key2Data"} {
+		keyRef, getKeyErr := leaf.GetKeyDataRefByIndex(uint32(index))
+		assert.NoError(t, getKeyErr)
+		assert.Equal(t, []byte(data), leaf.GetKeyRefData(keyRef))
+	}
+}
+
+func TestUpdateLeafNodeSplitsWhenFull(t *testing.T) {
+	leaf := NewEmptyLeafNode(120)
+	for key, data := range []string{"key0Data", "key1Data", "key2Data", "key3Data", "key4Data", "key5Data"} {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte(data), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	// the largest entry stored inline in the node
+	largeData := bytes.Repeat([]byte("x"), int(MaxInlineEntrySize(120))-KEY_DATA_REF_SIZE-1)
+	updateResult, updateErr := leaf.UpdateAtIndex(1, largeData, BytewiseComparator)
+	assert.NoError(t, updateErr)
+	assert.NotNil(t, updateResult.Metadata.Split)
+	assert.Equal(t, largeData, leaf.GetKeyRefData(updateResult.InsertedKeyDataRef))
+
+	_, tooLargeErr := leaf.UpdateAtIndex(0, make([]byte, 350), BytewiseComparator)
+	assert.ErrorIs(t, tooLargeErr, ErrNoAvailableSpaceForInsert)
+}
+
+func TestInsertCompactsFragmentedLeafInsteadOfSplitting(t *testing.T) {
+	leaf := NewEmptyLeafNode(160)
+	for key := 0; key < 8; key++ {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte("key-data"), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	// entries in the middle of the data area become fragmented bytes
+	assert.NoError(t, leaf.DeleteAtIndex(1))
+	assert.NoError(t, leaf.DeleteAtIndex(2))
+	assert.Equal(t, uint32(2*(1+len("key-data"))), leaf.GetHeader().FragmentedBytes)
+
+	// the entry only fits into the node once the fragmented bytes are reclaimed
+	largeData := bytes.Repeat([]byte("x"), 45)
+	requiredSpace := uint32(KEY_DATA_REF_SIZE + 1 + len(largeData))
+	assert.Less(t, leaf.GetHeader().GetAvailableSpace(), requiredSpace)
+	assert.GreaterOrEqual(t, leaf.GetHeader().GetFreeSpace(), requiredSpace)
+
+	insertResult, insertErr := leaf.Insert([]byte{8}, largeData, BytewiseComparator)
+	assert.NoError(t, insertErr)
+	assert.Nil(t, insertResult.Metadata.Split)
+	assert.Zero(t, leaf.GetHeader().FragmentedBytes)
+
+	for index, key := range []byte{0, 2, 4, 5, 6, 7, 8} {
+		keyRef, getKeyErr := leaf.GetKeyDataRefByIndex(uint32(index))
+		assert.NoError(t, getKeyErr)
+		assert.Equal(t, []byte{key}, keyRef.Key)
+		if key == 8 {
+			assert.Equal(t, largeData, leaf.GetKeyRefData(keyRef))
+		} else {
+			assert.Equal(t, []byte("key-data"), leaf.GetKeyRefData(keyRef))
+		}
+	}
+}
+
+func TestSplitLeafReusesSpaceOfMovedEntries(t *testing.T) {
+	leaf := NewEmptyLeafNode(200)
+	var split *SplitMetadata
+	for key := 0; split == nil; key++ {
+		insertResult, insertErr := leaf.Insert([]byte{byte(key)}, []byte("key-data"), BytewiseComparator)
+		assert.NoError(t, insertErr)
+		split = insertResult.Metadata.Split
+	}
+
+	// the space of the moved entries is free again in the old leaf
+	movedEntries := split.CreatedNode.GetElementsCount()
+	usedSpace := leaf.GetElementsCount() * (KEY_DATA_REF_SIZE + 1 + uint32(len("key-data")))
+	assert.Equal(t, leaf.GetHeader().NodeSize-usedSpace, leaf.GetHeader().GetFreeSpace())
+	for key := 0; uint32(key) < movedEntries; key++ {
+		_, insertErr := leaf.Insert([]byte{byte(100 + key)}, []byte("key-data"), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+}
+
+func TestInsertOverflowKeepsFlagAcrossSplit(t *testing.T) {
+	leaf := NewEmptyLeafNode(60)
+
+	var split *SplitMetadata
+	for key := byte(0); split == nil; key++ {
+		pointer := &OverflowPointer{PageId: uint32(key) + 10, Length: 100000}
+		insertResult, insertErr := leaf.InsertOverflow([]byte{key}, pointer, BytewiseComparator)
+		assert.NoError(t, insertErr)
+		assert.True(t, insertResult.InsertedKeyDataRef.Overflow)
+		split = insertResult.Metadata.Split
+	}
+
+	for _, node := range []*LeafNode{leaf, split.CreatedNode.(*LeafNode)} {
+		assert.NotZero(t, node.GetElementsCount())
+		for index := uint32(0); index < node.GetElementsCount(); index++ {
+			keyRef, getKeyErr := node.GetKeyDataRefByIndex(index)
+			assert.NoError(t, getKeyErr)
+			assert.True(t, keyRef.Overflow)
+
+			pointer, decodeErr := DecodeOverflowPointer(node.GetKeyRefData(keyRef))
+			assert.NoError(t, decodeErr)
+			assert.Equal(t, uint32(keyRef.Key[0])+10, pointer.PageId)
+		}
+	}
+}
+
+func TestNeedsOverflow(t *testing.T) {
+	leaf := NewEmptyLeafNode(100)
+
+	assert.False(t, leaf.NeedsOverflow([]byte{1}, make([]byte, 23)))
+	assert.True(t, leaf.NeedsOverflow([]byte{1}, make([]byte, 24)))
+}
+
+func TestLeafNodeWriteEffects(t *testing.T) {
+	leaf := NewEmptyLeafNode(120)
+	for key, data := range []string{"key0Data", "key1Data", "key2Data", "key3Data", "key4Data", "key5Data"} {
+		_, insertErr := leaf.Insert([]byte{byte(key)}, []byte(data), BytewiseComparator)
+		assert.NoError(t, insertErr)
+	}
+
+	// a new last key becomes the high key
+	splits, newHighKey, effectsErr := leaf.WriteEffects([]byte{9}, []byte("data"), false, BytewiseComparator)
+	assert.NoError(t, effectsErr)
+	assert.False(t, splits)
+	assert.True(t, newHighKey)
+
+	splits, newHighKey, effectsErr = leaf.WriteEffects([]byte{1, 0}, make([]byte, 40), false, BytewiseComparator)
+	assert.NoError(t, effectsErr)
+	assert.True(t, splits)
+	assert.False(t, newHighKey)
+
+	// overflow values only need room for their pointer
+	splits, _, effectsErr = leaf.WriteEffects([]byte{1, 0}, make([]byte, 500), true, BytewiseComparator)
+	assert.NoError(t, effectsErr)
+	assert.False(t, splits)
+
+	// updates reuse the space of the old data
+	splits, newHighKey, effectsErr = leaf.WriteEffects([]byte{4}, make([]byte, 20), false, BytewiseComparator)
+	assert.NoError(t, effectsErr)
+	assert.False(t, splits)
+	assert.False(t, newHighKey)
+
+	splits, _, effectsErr = leaf.WriteEffects([]byte{4}, make([]byte, 30), false, BytewiseComparator)
+	assert.NoError(t, effectsErr)
+	assert.True(t, splits)
+
+	// the predicted split happens
+	updateResult, updateErr := leaf.UpdateAtIndex(4, make([]byte, 30), BytewiseComparator)
+	assert.NoError(t, updateErr)
+	assert.NotNil(t, updateResult.Metadata.Split)
+}
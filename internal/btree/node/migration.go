@@ -0,0 +1,92 @@
+package node
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// nodes written with LegacyNodeFormatVersion use 100 byte slots
+const LEGACY_KEY_REF_SIZE = 100
+
+// legacySlot holds the data length in leaves and the page id in internal
+// nodes
+type legacySlot struct {
+	Offset    uint32
+	KeyLength uint32
+	Value     uint32
+}
+
+// UpgradeNode rebuilds a node written with an older format version in the
+// current format, the given buffer is not modified
+func UpgradeNode(header *NodeHeader, data []byte) (Node, error) {
+	if header.FormatVersion != LegacyNodeFormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedNodeFormat, header.FormatVersion)
+	}
+
+	if uint64(header.ElementsCount)*LEGACY_KEY_REF_SIZE > uint64(len(data)) {
+		return nil, fmt.Errorf("failed to upgrade node: %d slots do not fit into the node", header.ElementsCount)
+	}
+
+	switch header.NodeType {
+	case LeafNodeType:
+		return upgradeLeafNode(header, data)
+	case InternalNodeType:
+		return upgradeInternalNode(header, data)
+	default:
+		return nil, fmt.Errorf("failed to upgrade node: unexpected node type %v", header.NodeType)
+	}
+}
+
+func upgradeLeafNode(header *NodeHeader, data []byte) (*LeafNode, error) {
+	leaf := NewEmptyLeafNode(header.NodeSize)
+	for index := uint32(0); index < header.ElementsCount; index++ {
+		slot, slotErr := decodeLegacySlot(data, index)
+		if slotErr != nil {
+			return nil, slotErr
+		}
+
+		entry, entryErr := readKey(data, slot.Offset, slot.KeyLength+slot.Value)
+		if entryErr != nil {
+			return nil, fmt.Errorf("failed to upgrade leaf node: %w", entryErr)
+		}
+
+		if _, err := leaf.append(entry[:slot.KeyLength], entry[slot.KeyLength:], false); err != nil {
+			return nil, fmt.Errorf("failed to upgrade leaf node: %w", err)
+		}
+	}
+
+	return leaf, nil
+}
+
+func upgradeInternalNode(header *NodeHeader, data []byte) (*InternalNode, error) {
+	internal := NewEmptyInternalNode(header.NodeSize)
+	for index := uint32(0); index < header.ElementsCount; index++ {
+		slot, slotErr := decodeLegacySlot(data, index)
+		if slotErr != nil {
+			return nil, slotErr
+		}
+
+		key, keyErr := readKey(data, slot.Offset, slot.KeyLength)
+		if keyErr != nil {
+			return nil, fmt.Errorf("failed to upgrade internal node: %w", keyErr)
+		}
+
+		if _, err := internal.append(key, slot.Value); err != nil {
+			return nil, fmt.Errorf("failed to upgrade internal node: %w", err)
+		}
+	}
+
+	return internal, nil
+}
+
+func decodeLegacySlot(data []byte, index uint32) (*legacySlot, error) {
+	offset := index * LEGACY_KEY_REF_SIZE
+	slot := &legacySlot{}
+	reader := bytes.NewReader(data[offset:(offset + LEGACY_KEY_REF_SIZE)])
+	if err := binary.Read(reader, binary.LittleEndian, slot); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy slot: %w", err)
+	}
+
+	return slot, nil
+}
@@ -0,0 +1,26 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeNodeRejectsUnknownVersion(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	header := *leaf.GetHeader()
+	header.FormatVersion = NODE_FORMAT_VERSION + 1
+
+	_, upgradeErr := UpgradeNode(&header, leaf.GetBuffer())
+	assert.ErrorIs(t, upgradeErr, ErrUnsupportedNodeFormat)
+}
+
+func TestUpgradeNodeRejectsSlotsOutOfNode(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	header := *leaf.GetHeader()
+	header.FormatVersion = LegacyNodeFormatVersion
+	header.ElementsCount = 11
+
+	_, upgradeErr := UpgradeNode(&header, leaf.GetBuffer())
+	assert.Error(t, upgradeErr)
+}
@@ -1,7 +1,11 @@
 package node
 
+// internal nodes keep room for one key of this size so separator keys can
+// always be replaced
+const MAX_KEY_SIZE = 256
+
 type KeyReference interface {
-	GetKey() uint32
+	GetKey() []byte
 }
 
 type Node interface {
@@ -9,5 +13,6 @@ type Node interface {
 	GetElementsCount() uint32
 	GetHeader() *NodeHeader
 	GetBuffer() []byte
-	GetMaxKey() (uint32, error)
+	GetMaxKey() ([]byte, error)
+	Clone() Node
 }
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// first page id u32, value length u32
+const OVERFLOW_POINTER_SIZE = 8
+
+// OverflowPointer is the data of an overflow entry, it points to the first
+// page of the chain holding the value
+type OverflowPointer struct {
+	PageId uint32
+	Length uint32
+}
+
+func (p *OverflowPointer) Encode() []byte {
+	buf := make([]byte, OVERFLOW_POINTER_SIZE)
+	binary.LittleEndian.PutUint32(buf[0:4], p.PageId)
+	binary.LittleEndian.PutUint32(buf[4:8], p.Length)
+	return buf
+}
+
+func DecodeOverflowPointer(data []byte) (*OverflowPointer, error) {
+	if len(data) != OVERFLOW_POINTER_SIZE {
+		return nil, fmt.Errorf("failed to decode overflow pointer: got %d bytes", len(data))
+	}
+
+	return &OverflowPointer{
+		binary.LittleEndian.Uint32(data[0:4]),
+		binary.LittleEndian.Uint32(data[4:8]),
+	}, nil
+}
+
+// MaxInlineEntrySize returns the size of the largest entry, its reference
+// included, a leaf of the node size stores inline. Inline entries stay below
+// a third of the node, so any three of them fit on one side of a split.
+func MaxInlineEntrySize(nodeSize uint32) uint32 {
+	return nodeSize/3 - 1
+}
+
+// NeedsOverflow reports whether the value has to be stored in overflow pages
+func (l *LeafNode) NeedsOverflow(key []byte, data []byte) bool {
+	return KEY_DATA_REF_SIZE+uint32(len(key)+len(data)) > MaxInlineEntrySize(l.header.NodeSize)
+}
@@ -1,6 +1,6 @@
 package node
 
-func FindPositionForKey(node Node, key uint32) (bool, uint32, error) {
+func FindPositionForKey(node Node, key []byte, comparator Comparator) (bool, uint32, error) {
 	start := uint32(0)
 	end := node.GetElementsCount()
 	for start < end {
@@ -10,11 +10,12 @@ func FindPositionForKey(node Node, key uint32) (bool, uint32, error) {
 			return false, 0, err
 		}
 
-		if middleKeyRef.GetKey() == key {
+		comparison := comparator.Compare(middleKeyRef.GetKey(), key)
+		if comparison == 0 {
 			return true, middle, nil
 		}
 
-		if middleKeyRef.GetKey() < key {
+		if comparison < 0 {
 			start = middle + 1
 		} else {
 			end = middle
@@ -24,7 +25,7 @@ func FindPositionForKey(node Node, key uint32) (bool, uint32, error) {
 	return false, start, nil
 }
 
-func FindPositionForKeyInRefs[T KeyReference](key uint32, refs []T) (bool, uint32) {
+func FindPositionForKeyInRefs[T KeyReference](key []byte, refs []T, comparator Comparator) (bool, uint32) {
 	// TODO: just use int
 	start := uint32(0)
 	end := uint32(len(refs))
@@ -32,11 +33,12 @@ func FindPositionForKeyInRefs[T KeyReference](key uint32, refs []T) (bool, uint3
 		middle := (start + end) / 2
 		middleKeyRef := refs[middle]
 
-		if middleKeyRef.GetKey() == key {
+		comparison := comparator.Compare(middleKeyRef.GetKey(), key)
+		if comparison == 0 {
 			return true, middle
 		}
 
-		if middleKeyRef.GetKey() < key {
+		if comparison < 0 {
 			start = middle + 1
 		} else {
 			end = middle
@@ -0,0 +1,52 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPositionForKey(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	data := []byte("data")
+	key := []byte{1}
+
+	_, insertErr := leaf.Insert(key, data, BytewiseComparator)
+	assert.NoError(t, insertErr)
+
+	key2 := []byte{0}
+	exists, index, err := FindPositionForKey(leaf, key2, BytewiseComparator)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, uint32(0), index)
+}
+
+type reverseComparator struct{}
+
+func (reverseComparator) Compare(a []byte, b []byte) int {
+	return BytewiseComparator.Compare(b, a)
+}
+
+func (reverseComparator) Name() string {
+	return "reverse"
+}
+
+func TestFindPositionForKeyWithComparator(t *testing.T) {
+	leaf := NewEmptyLeafNode(1024)
+	for _, key := range [][]byte{{1}, {3}, {2}} {
+		_, insertErr := leaf.Insert(key, []byte("data"), reverseComparator{})
+		assert.NoError(t, insertErr)
+	}
+
+	// keys are kept in the order of the comparator
+	for index, expectedKey := range [][]byte{{3}, {2}, {1}} {
+		keyRef, err := leaf.GetKeyDataRefByIndex(uint32(index))
+		assert.NoError(t, err)
+		assert.Equal(t, expectedKey, keyRef.Key)
+	}
+
+	exists, index, err := FindPositionForKey(leaf, []byte{0}, reverseComparator{})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, uint32(3), index)
+}
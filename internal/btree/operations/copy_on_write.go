@@ -0,0 +1,58 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"errors"
+)
+
+// writeNode persists a changed node and reports whether its parent changed.
+// A committed page that can't be overwritten is left to the open snapshots,
+// the node is copied to a new page and the parent, or the root page id when
+// the node has no parent, points to the copy. The old page is freed once
+// the transaction commits.
+func writeNode(pager *pg.Pager, pagedNode *pg.PagedNode, parentNode *node.InternalNode, index uint32) (bool, error) {
+	if pager.CanOverwrite(pagedNode.Page) {
+		return false, pager.WritePagedNode(pagedNode)
+	}
+
+	copied, writeErr := pager.WriteNewNode(pagedNode.Node)
+	if writeErr != nil {
+		return false, writeErr
+	}
+
+	if err := pager.FreePage(pagedNode.Page); err != nil {
+		return false, err
+	}
+	pagedNode.Page = copied.Page
+
+	if parentNode == nil {
+		return false, pager.UpdateRootPage(pagedNode.Page)
+	}
+
+	keyRef, keyRefErr := parentNode.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return false, keyRefErr
+	}
+
+	if _, err := parentNode.UpdateAtIndex(index, keyRef.Key, pagedNode.Page); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// writeBreadcrumb persists the node of the breadcrumb at the level
+func writeBreadcrumb(pager *pg.Pager, breadcrumbs []*breadcrumb, level int) (bool, error) {
+	currentNodeBreadcrumb := breadcrumbs[level]
+	if level == 0 {
+		return writeNode(pager, currentNodeBreadcrumb.pagedNode, nil, 0)
+	}
+
+	parentNode, parentNodeOk := breadcrumbs[level-1].pagedNode.Node.(*node.InternalNode)
+	if !parentNodeOk {
+		return false, errors.New("failed to cast parent node to internal node")
+	}
+
+	return writeNode(pager, currentNodeBreadcrumb.pagedNode, parentNode, currentNodeBreadcrumb.index)
+}
@@ -0,0 +1,73 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyOnWriteKeepsSnapshot(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "copy_on_write.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 500))
+	defer pager.CloseFile()
+
+	snapshot := pager.BeginRead()
+	rootPageId := snapshot.RootPageId()
+
+	// the writer copies the pages the snapshot reads, the deletes copy the
+	// nodes they rebalance
+	writer := pager.NewWriter()
+	for key := uint32(0); key < 500; key++ {
+		assert.NoError(t, Upsert(writer, uint32Key(key), []byte("new")))
+	}
+	for key := uint32(0); key < 500; key += 2 {
+		assert.NoError(t, Delete(pager, uint32Key(key)))
+	}
+	assert.NotEqual(t, rootPageId, pager.RootPageId())
+
+	assert.Equal(t, rootPageId, snapshot.RootPageId())
+	for key := uint32(0); key < 500; key++ {
+		data, getErr := Get(snapshot, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+	}
+	snapshot.EndRead()
+
+	for key := uint32(0); key < 500; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		if key%2 == 0 {
+			assert.ErrorIs(t, getErr, ErrKeyNotFound)
+		} else {
+			assert.NoError(t, getErr)
+			assert.Equal(t, []byte("new"), data)
+		}
+	}
+}
+
+func TestCopyOnWriteReusesReleasedPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "copy_on_write_reuse.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 500))
+	defer pager.CloseFile()
+	pageCount := pager.PageCount()
+
+	// every round copies the tree, the copies of the previous rounds are
+	// reused once their snapshot is released
+	writer := pager.NewWriter()
+	for round := 0; round < 10; round++ {
+		snapshot := pager.BeginRead()
+		for key := uint32(0); key < 500; key++ {
+			assert.NoError(t, Upsert(writer, uint32Key(key), []byte(fmt.Sprint(round))))
+		}
+		snapshot.EndRead()
+	}
+
+	assert.Less(t, pager.PageCount(), 3*pageCount)
+}
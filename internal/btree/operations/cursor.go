@@ -0,0 +1,439 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type Bound struct {
+	Key       []byte
+	Inclusive bool
+}
+
+// excludesFromStart reports whether the key is below the bound when it is used as the
+// start of a range
+func (b *Bound) excludesFromStart(key []byte, comparator node.Comparator) bool {
+	comparison := comparator.Compare(key, b.Key)
+	return comparison < 0 || (comparison == 0 && !b.Inclusive)
+}
+
+// excludesFromEnd reports whether the key is above the bound when it is used
+// as the end of a range
+func (b *Bound) excludesFromEnd(key []byte, comparator node.Comparator) bool {
+	comparison := comparator.Compare(key, b.Key)
+	return comparison > 0 || (comparison == 0 && !b.Inclusive)
+}
+
+// Range limits the keys visited by a cursor, nil bounds are unbounded
+type Range struct {
+	Start *Bound
+	End   *Bound
+}
+
+// Contains reports whether the key is within the bounds of the range
+func (r *Range) Contains(key []byte, comparator node.Comparator) bool {
+	if r.Start != nil && r.Start.excludesFromStart(key, comparator) {
+		return false
+	}
+
+	return r.End == nil || !r.End.excludesFromEnd(key, comparator)
+}
+
+// Cursor iterates keys in ascending or descending order. It keeps the path
+// from the root to the current leaf, so moving to a neighbouring leaf only
+// backtracks to the closest common parent. The tree must not be modified while iterating,
+// reposition the cursor with First or Seek after a write.
+type Cursor struct {
+	pager       *pg.Pager
+	keyRange    *Range
+	breadcrumbs []*breadcrumb
+	index       uint32
+	keyRef      *node.KeyDataReference
+	err         error
+}
+
+func NewCursor(pager *pg.Pager, keyRange *Range) *Cursor {
+	if keyRange == nil {
+		keyRange = &Range{}
+	}
+
+	return &Cursor{pager: pager, keyRange: keyRange}
+}
+
+// First positions the cursor at the lowest key in the range
+func (c *Cursor) First() bool {
+	c.reset()
+
+	if c.keyRange.Start != nil {
+		return c.seek(c.keyRange.Start.Key)
+	}
+
+	rootPagedNode, rootNodeErr := c.pager.ReadRootNode()
+	if rootNodeErr != nil {
+		return c.fail(fmt.Errorf("failed to read root node: %w", rootNodeErr))
+	}
+
+	c.breadcrumbs = []*breadcrumb{{rootPagedNode, 0, nil, true}}
+	if err := c.descendToFirstLeaf(); err != nil {
+		return c.fail(err)
+	}
+
+	return c.settleForward()
+}
+
+// Seek positions the cursor at the lowest key in the range that is greater
+// or equal to the given key
+func (c *Cursor) Seek(key []byte) bool {
+	c.reset()
+
+	if c.keyRange.Start != nil && c.pager.Comparator().Compare(key, c.keyRange.Start.Key) <= 0 {
+		return c.seek(c.keyRange.Start.Key)
+	}
+
+	return c.seek(key)
+}
+
+// Last positions the cursor at the highest key in the range
+func (c *Cursor) Last() bool {
+	c.reset()
+
+	if c.keyRange.End != nil {
+		return c.seekForPrev(c.keyRange.End.Key)
+	}
+
+	rootPagedNode, rootNodeErr := c.pager.ReadRootNode()
+	if rootNodeErr != nil {
+		return c.fail(fmt.Errorf("failed to read root node: %w", rootNodeErr))
+	}
+
+	c.breadcrumbs = []*breadcrumb{{rootPagedNode, 0, nil, true}}
+	if err := c.descendToLastLeaf(); err != nil {
+		return c.fail(err)
+	}
+
+	c.index = c.leaf().GetElementsCount()
+	return c.stepBackAndSettle()
+}
+
+// SeekForPrev positions the cursor at the highest key in the range that is
+// lower or equal to the given key
+func (c *Cursor) SeekForPrev(key []byte) bool {
+	c.reset()
+
+	if c.keyRange.End != nil && c.pager.Comparator().Compare(key, c.keyRange.End.Key) >= 0 {
+		return c.seekForPrev(c.keyRange.End.Key)
+	}
+
+	return c.seekForPrev(key)
+}
+
+// Next moves the cursor to the following key in the range
+func (c *Cursor) Next() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	c.index += 1
+	return c.settleForward()
+}
+
+// Prev moves the cursor to the preceding key in the range
+func (c *Cursor) Prev() bool {
+	if !c.Valid() {
+		return false
+	}
+
+	return c.stepBackAndSettle()
+}
+
+func (c *Cursor) Valid() bool {
+	return c.keyRef != nil && c.err == nil
+}
+
+// Key returns a copy of the current key
+func (c *Cursor) Key() []byte {
+	if !c.Valid() {
+		return nil
+	}
+
+	return bytes.Clone(c.keyRef.Key)
+}
+
+// Value returns a copy of the data stored under the current key, a failure
+// to read the overflow pages of the value is reported by Err
+func (c *Cursor) Value() []byte {
+	if !c.Valid() {
+		return nil
+	}
+
+	data, readErr := readValue(c.pager, c.leaf(), c.keyRef)
+	if readErr != nil {
+		c.err = readErr
+		return nil
+	}
+
+	return data
+}
+
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+func (c *Cursor) reset() {
+	c.breadcrumbs = nil
+	c.index = 0
+	c.keyRef = nil
+	c.err = nil
+}
+
+func (c *Cursor) fail(err error) bool {
+	c.keyRef = nil
+	c.err = err
+	return false
+}
+
+func (c *Cursor) leaf() *node.LeafNode {
+	// breadcrumbs always end in a leaf node, see findPosition
+	return c.breadcrumbs[len(c.breadcrumbs)-1].pagedNode.Node.(*node.LeafNode)
+}
+
+func (c *Cursor) seek(key []byte) bool {
+	breadcrumbs, searchErr := findPosition(c.pager, key)
+	if searchErr != nil {
+		return c.fail(searchErr)
+	}
+
+	c.breadcrumbs = breadcrumbs
+	_, index, findErr := node.FindPositionForKey(c.leaf(), key, c.pager.Comparator())
+	if findErr != nil {
+		return c.fail(fmt.Errorf("failed to find position of key %q: %w", key, findErr))
+	}
+
+	c.index = index
+	return c.settleForward()
+}
+
+func (c *Cursor) seekForPrev(key []byte) bool {
+	breadcrumbs, searchErr := findPosition(c.pager, key)
+	if searchErr != nil {
+		return c.fail(searchErr)
+	}
+
+	c.breadcrumbs = breadcrumbs
+	exists, index, findErr := node.FindPositionForKey(c.leaf(), key, c.pager.Comparator())
+	if findErr != nil {
+		return c.fail(fmt.Errorf("failed to find position of key %q: %w", key, findErr))
+	}
+
+	if exists {
+		c.index = index
+		return c.settleBackward()
+	}
+
+	// index points to the first greater key, step back from it
+	c.index = index
+	return c.stepBackAndSettle()
+}
+
+// settleForward moves the cursor from the current index to the first
+// existing key within the range, moving to the following leaves if needed
+func (c *Cursor) settleForward() bool {
+	for {
+		for c.index >= c.leaf().GetElementsCount() {
+			hasNext, nextErr := c.moveToNextLeaf()
+			if nextErr != nil {
+				return c.fail(nextErr)
+			}
+
+			if !hasNext {
+				c.keyRef = nil
+				return false
+			}
+		}
+
+		keyRef, keyRefErr := c.leaf().GetKeyDataRefByIndex(c.index)
+		if keyRefErr != nil {
+			return c.fail(keyRefErr)
+		}
+
+		if start := c.keyRange.Start; start != nil && start.excludesFromStart(keyRef.Key, c.pager.Comparator()) {
+			c.index += 1
+			continue
+		}
+
+		if end := c.keyRange.End; end != nil && end.excludesFromEnd(keyRef.Key, c.pager.Comparator()) {
+			c.keyRef = nil
+			return false
+		}
+
+		c.keyRef = keyRef
+		return true
+	}
+}
+
+// settleBackward moves the cursor from the current index to the last
+// existing key within the range, moving to the preceding leaves if needed
+func (c *Cursor) settleBackward() bool {
+	for {
+		keyRef, keyRefErr := c.leaf().GetKeyDataRefByIndex(c.index)
+		if keyRefErr != nil {
+			return c.fail(keyRefErr)
+		}
+
+		if end := c.keyRange.End; end != nil && end.excludesFromEnd(keyRef.Key, c.pager.Comparator()) {
+			if hasPrev, prevErr := c.stepBack(); prevErr != nil || !hasPrev {
+				return c.stop(prevErr)
+			}
+			continue
+		}
+
+		if start := c.keyRange.Start; start != nil && start.excludesFromStart(keyRef.Key, c.pager.Comparator()) {
+			c.keyRef = nil
+			return false
+		}
+
+		c.keyRef = keyRef
+		return true
+	}
+}
+
+func (c *Cursor) stepBackAndSettle() bool {
+	hasPrev, prevErr := c.stepBack()
+	if prevErr != nil || !hasPrev {
+		return c.stop(prevErr)
+	}
+
+	return c.settleBackward()
+}
+
+// stepBack moves the cursor to the preceding index, moving to the preceding
+// non empty leaf when the current index is the first one
+func (c *Cursor) stepBack() (bool, error) {
+	for c.index == 0 {
+		hasPrev, prevErr := c.moveToPrevLeaf()
+		if prevErr != nil || !hasPrev {
+			return false, prevErr
+		}
+	}
+
+	c.index -= 1
+	return true, nil
+}
+
+// stop invalidates the cursor, recording the error if there is one
+func (c *Cursor) stop(err error) bool {
+	if err != nil {
+		return c.fail(err)
+	}
+
+	c.keyRef = nil
+	return false
+}
+
+// moveToNextLeaf backtracks to the closest parent with a following child and
+// descends to the left most leaf of that child
+func (c *Cursor) moveToNextLeaf() (bool, error) {
+	for level := len(c.breadcrumbs) - 1; level > 0; level-- {
+		parent, parentOk := c.breadcrumbs[level-1].pagedNode.Node.(*node.InternalNode)
+		if !parentOk {
+			return false, errors.New("failed to cast parent node to internal node")
+		}
+
+		nextIndex := c.breadcrumbs[level].index + 1
+		if nextIndex >= parent.GetElementsCount() {
+			continue
+		}
+
+		c.breadcrumbs = c.breadcrumbs[:level]
+		if err := c.descend(nextIndex); err != nil {
+			return false, err
+		}
+
+		if err := c.descendToFirstLeaf(); err != nil {
+			return false, err
+		}
+
+		c.index = 0
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// moveToPrevLeaf backtracks to the closest parent with a preceding child and
+// descends to the right most leaf of that child. The cursor index is set
+// past the last key of the leaf.
+func (c *Cursor) moveToPrevLeaf() (bool, error) {
+	for level := len(c.breadcrumbs) - 1; level > 0; level-- {
+		index := c.breadcrumbs[level].index
+		if index == 0 {
+			continue
+		}
+
+		c.breadcrumbs = c.breadcrumbs[:level]
+		if err := c.descend(index - 1); err != nil {
+			return false, err
+		}
+
+		if err := c.descendToLastLeaf(); err != nil {
+			return false, err
+		}
+
+		c.index = c.leaf().GetElementsCount()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// descend appends the child at the given index of the last breadcrumb
+func (c *Cursor) descend(index uint32) error {
+	parentBreadcrumb := c.breadcrumbs[len(c.breadcrumbs)-1]
+	parent, parentOk := parentBreadcrumb.pagedNode.Node.(*node.InternalNode)
+	if !parentOk {
+		return errors.New("failed to cast parent node to internal node")
+	}
+
+	keyRef, keyRefErr := parent.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return keyRefErr
+	}
+
+	pagedNode, readErr := c.pager.ReadPagedNode(keyRef.PageId)
+	if readErr != nil {
+		return readErr
+	}
+
+	isRightMostNode := index == parent.GetElementsCount()-1
+	c.breadcrumbs = append(c.breadcrumbs, &breadcrumb{pagedNode, index, keyRef.GetKey(), isRightMostNode})
+	return nil
+}
+
+func (c *Cursor) descendToFirstLeaf() error {
+	for {
+		current := c.breadcrumbs[len(c.breadcrumbs)-1].pagedNode
+		if current.GetNodeType() == node.LeafNodeType {
+			return nil
+		}
+
+		if err := c.descend(0); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Cursor) descendToLastLeaf() error {
+	for {
+		current := c.breadcrumbs[len(c.breadcrumbs)-1].pagedNode
+		if current.GetNodeType() == node.LeafNodeType {
+			return nil
+		}
+
+		if err := c.descend(current.Node.GetElementsCount() - 1); err != nil {
+			return err
+		}
+	}
+}
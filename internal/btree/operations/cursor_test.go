@@ -0,0 +1,276 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/pager"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCursorTestPager(t *testing.T, dbFileName string, keys []uint32) *pager.Pager {
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	for _, key := range keys {
+		insertErr := Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key)))
+		assert.NoError(t, insertErr)
+	}
+
+	return pager
+}
+
+func collectKeys(cursor *Cursor, valid bool) []uint32 {
+	keys := []uint32{}
+	for ok := valid; ok; ok = cursor.Next() {
+		keys = append(keys, uint32FromKey(cursor.Key()))
+	}
+
+	return keys
+}
+
+func keysInRange(start uint32, end uint32) []uint32 {
+	keys := []uint32{}
+	for key := start; key < end; key++ {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func TestCursorIteratesAllKeysAcrossLeaves(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 2000))
+
+	cursor := NewCursor(pager, nil)
+	keys := []uint32{}
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		keys = append(keys, uint32FromKey(cursor.Key()))
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", uint32FromKey(cursor.Key()))), cursor.Value())
+	}
+
+	assert.NoError(t, cursor.Err())
+	assert.Equal(t, keysInRange(0, 2000), keys)
+	assert.False(t, cursor.Valid())
+}
+
+func TestCursorOnEmptyTree(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, nil)
+
+	cursor := NewCursor(pager, nil)
+	assert.False(t, cursor.First())
+	assert.False(t, cursor.Seek(uint32Key(10)))
+	assert.NoError(t, cursor.Err())
+}
+
+func TestCursorSeek(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	var evenKeys []uint32
+	for key := uint32(0); key < 400; key += 2 {
+		evenKeys = append(evenKeys, key)
+	}
+	pager := newCursorTestPager(t, dbFileName, evenKeys)
+
+	cursor := NewCursor(pager, nil)
+	assert.True(t, cursor.Seek(uint32Key(100)))
+	assert.Equal(t, uint32(100), uint32FromKey(cursor.Key()))
+
+	assert.True(t, cursor.Seek(uint32Key(101)))
+	assert.Equal(t, uint32(102), uint32FromKey(cursor.Key()))
+	assert.True(t, cursor.Next())
+	assert.Equal(t, uint32(104), uint32FromKey(cursor.Key()))
+
+	assert.False(t, cursor.Seek(uint32Key(399)))
+	assert.NoError(t, cursor.Err())
+}
+
+func TestCursorRangeBounds(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 300))
+
+	inclusive := NewCursor(pager, &Range{&Bound{uint32Key(100), true}, &Bound{uint32Key(200), true}})
+	assert.Equal(t, keysInRange(100, 201), collectKeys(inclusive, inclusive.First()))
+
+	exclusive := NewCursor(pager, &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(200), false}})
+	assert.Equal(t, keysInRange(101, 200), collectKeys(exclusive, exclusive.First()))
+
+	startOnly := NewCursor(pager, &Range{Start: &Bound{uint32Key(250), true}})
+	assert.Equal(t, keysInRange(250, 300), collectKeys(startOnly, startOnly.First()))
+
+	endOnly := NewCursor(pager, &Range{End: &Bound{uint32Key(50), false}})
+	assert.Equal(t, keysInRange(0, 50), collectKeys(endOnly, endOnly.First()))
+
+	// seeking before the start of the range positions the cursor at the start
+	seekBeforeStart := NewCursor(pager, &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(105), true}})
+	assert.Equal(t, keysInRange(101, 106), collectKeys(seekBeforeStart, seekBeforeStart.Seek(uint32Key(10))))
+
+	seekAfterEnd := NewCursor(pager, &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(105), true}})
+	assert.False(t, seekAfterEnd.Seek(uint32Key(106)))
+
+	keyRange := &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(105), true}}
+	assert.False(t, keyRange.Contains(uint32Key(100), node.BytewiseComparator))
+	assert.True(t, keyRange.Contains(uint32Key(101), node.BytewiseComparator))
+	assert.True(t, keyRange.Contains(uint32Key(105), node.BytewiseComparator))
+	assert.False(t, keyRange.Contains(uint32Key(106), node.BytewiseComparator))
+	assert.True(t, (&Range{}).Contains(uint32Key(0), node.BytewiseComparator))
+}
+
+func reverseKeys(keys []uint32) []uint32 {
+	reversed := []uint32{}
+	for i := len(keys) - 1; i >= 0; i-- {
+		reversed = append(reversed, keys[i])
+	}
+
+	return reversed
+}
+
+func collectKeysBackward(cursor *Cursor, valid bool) []uint32 {
+	keys := []uint32{}
+	for ok := valid; ok; ok = cursor.Prev() {
+		keys = append(keys, uint32FromKey(cursor.Key()))
+	}
+
+	return keys
+}
+
+func TestCursorIteratesAllKeysBackward(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 2000))
+
+	cursor := NewCursor(pager, nil)
+	keys := []uint32{}
+	for ok := cursor.Last(); ok; ok = cursor.Prev() {
+		keys = append(keys, uint32FromKey(cursor.Key()))
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", uint32FromKey(cursor.Key()))), cursor.Value())
+	}
+
+	assert.NoError(t, cursor.Err())
+	assert.Equal(t, reverseKeys(keysInRange(0, 2000)), keys)
+}
+
+func TestCursorBackwardOnEmptyTree(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, nil)
+
+	cursor := NewCursor(pager, nil)
+	assert.False(t, cursor.Last())
+	assert.False(t, cursor.SeekForPrev(uint32Key(10)))
+	assert.NoError(t, cursor.Err())
+}
+
+func TestCursorSeekForPrev(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	var evenKeys []uint32
+	for key := uint32(2); key < 400; key += 2 {
+		evenKeys = append(evenKeys, key)
+	}
+	pager := newCursorTestPager(t, dbFileName, evenKeys)
+
+	cursor := NewCursor(pager, nil)
+	assert.True(t, cursor.SeekForPrev(uint32Key(100)))
+	assert.Equal(t, uint32(100), uint32FromKey(cursor.Key()))
+
+	assert.True(t, cursor.SeekForPrev(uint32Key(101)))
+	assert.Equal(t, uint32(100), uint32FromKey(cursor.Key()))
+	assert.True(t, cursor.Prev())
+	assert.Equal(t, uint32(98), uint32FromKey(cursor.Key()))
+
+	// direction can change at any position
+	assert.True(t, cursor.Next())
+	assert.Equal(t, uint32(100), uint32FromKey(cursor.Key()))
+
+	assert.True(t, cursor.SeekForPrev(uint32Key(1000)))
+	assert.Equal(t, uint32(398), uint32FromKey(cursor.Key()))
+
+	assert.False(t, cursor.SeekForPrev(uint32Key(1)))
+	assert.NoError(t, cursor.Err())
+}
+
+func TestCursorBackwardRangeBounds(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 300))
+
+	inclusive := NewCursor(pager, &Range{&Bound{uint32Key(100), true}, &Bound{uint32Key(200), true}})
+	assert.Equal(t, reverseKeys(keysInRange(100, 201)), collectKeysBackward(inclusive, inclusive.Last()))
+
+	exclusive := NewCursor(pager, &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(200), false}})
+	assert.Equal(t, reverseKeys(keysInRange(101, 200)), collectKeysBackward(exclusive, exclusive.Last()))
+
+	startOnly := NewCursor(pager, &Range{Start: &Bound{uint32Key(250), false}})
+	assert.Equal(t, reverseKeys(keysInRange(251, 300)), collectKeysBackward(startOnly, startOnly.Last()))
+
+	endOnly := NewCursor(pager, &Range{End: &Bound{uint32Key(50), true}})
+	assert.Equal(t, reverseKeys(keysInRange(0, 51)), collectKeysBackward(endOnly, endOnly.Last()))
+
+	// seeking past the end of the range positions the cursor at the end
+	seekAfterEnd := NewCursor(pager, &Range{&Bound{uint32Key(100), true}, &Bound{uint32Key(105), false}})
+	assert.Equal(t, reverseKeys(keysInRange(100, 105)), collectKeysBackward(seekAfterEnd, seekAfterEnd.SeekForPrev(uint32Key(1000))))
+
+	seekBeforeStart := NewCursor(pager, &Range{&Bound{uint32Key(100), false}, &Bound{uint32Key(105), true}})
+	assert.False(t, seekBeforeStart.SeekForPrev(uint32Key(100)))
+}
+
+type reverseComparator struct{}
+
+func (reverseComparator) Compare(a []byte, b []byte) int {
+	return node.BytewiseComparator.Compare(b, a)
+}
+
+func (reverseComparator) Name() string {
+	return "reverse"
+}
+
+func TestCursorFollowsComparatorOrder(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "cursor_comparator.db"
+	defer os.Remove(dbFileName)
+
+	options := pager.DefaultOptions()
+	options.Comparator = reverseComparator{}
+	pager, pagerErr := pager.NewPagerWithOptions(dbFileName, options)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, Init(pager))
+
+	// insert in the comparator order so new keys always land in the last leaf
+	for key := uint32(500); key > 0; key-- {
+		assert.NoError(t, Insert(pager, uint32Key(key-1), []byte(fmt.Sprintf("data%d", key-1))))
+	}
+
+	cursor := NewCursor(pager, &Range{&Bound{uint32Key(300), true}, &Bound{uint32Key(100), false}})
+	assert.Equal(t, reverseKeys(keysInRange(101, 301)), collectKeys(cursor, cursor.First()))
+	assert.NoError(t, cursor.Err())
+
+	data, getErr := Get(pager, uint32Key(42))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("data42"), data)
+}
@@ -0,0 +1,283 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+func Delete(pager *pg.Pager, key []byte) error {
+	return pager.Atomic(func() error {
+		return deleteKey(pager, key)
+	})
+}
+
+func deleteKey(pager *pg.Pager, key []byte) error {
+	breadcrumbs, searchErr := findPosition(pager, key)
+	if searchErr != nil {
+		return searchErr
+	}
+
+	leafBreadcrumb := breadcrumbs[len(breadcrumbs)-1]
+	leaf, leafOk := leafBreadcrumb.pagedNode.Node.(*node.LeafNode)
+	if !leafOk {
+		return fmt.Errorf("unable to cast to leaf node")
+	}
+
+	exists, index, findErr := node.FindPositionForKey(leaf, key, pager.Comparator())
+	if findErr != nil {
+		return fmt.Errorf("failed to find position of key %q: %w", key, findErr)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	keyRef, keyRefErr := leaf.GetKeyDataRefByIndex(index)
+	if keyRefErr != nil {
+		return keyRefErr
+	}
+
+	if err := freeOverflowValue(pager, leaf, keyRef); err != nil {
+		return err
+	}
+
+	if err := leaf.DeleteAtIndex(index); err != nil {
+		return err
+	}
+
+	return propagateDeleteUpdates(pager, breadcrumbs)
+}
+
+// propagateDeleteUpdates walks from the leaf towards the root, rebalancing
+// underflowing nodes and updating high keys in parents. It stops at the first
+// level whose parent did not change.
+func propagateDeleteUpdates(pager *pg.Pager, breadcrumbs []*breadcrumb) error {
+	for level := len(breadcrumbs) - 1; level > 0; level-- {
+		parentChanged, updateErr := handleDeleteInNode(pager, breadcrumbs[level], breadcrumbs[level-1])
+		if updateErr != nil {
+			return updateErr
+		}
+
+		if !parentChanged {
+			return nil
+		}
+	}
+
+	return handleDeleteInRoot(pager, breadcrumbs[0])
+}
+
+// handleDeleteInNode persists the current node after a key was removed from
+// it, it reports whether the parent node was modified and has to be persisted
+func handleDeleteInNode(pager *pg.Pager, currentNodeBreadcrumb *breadcrumb, parentNodeBreadcrumb *breadcrumb) (bool, error) {
+	parentNode, parentNodeOk := parentNodeBreadcrumb.pagedNode.Node.(*node.InternalNode)
+	if !parentNodeOk {
+		return false, errors.New("failed to cast parent node to internal node")
+	}
+
+	if currentNodeBreadcrumb.pagedNode.Node.GetHeader().IsUnderflow() && parentNode.GetElementsCount() > 1 {
+		return true, rebalance(pager, currentNodeBreadcrumb, parentNode)
+	}
+
+	copied, writeErr := writeNode(pager, currentNodeBreadcrumb.pagedNode, parentNode, currentNodeBreadcrumb.index)
+	if writeErr != nil {
+		return false, writeErr
+	}
+
+	highKeyChanged, updateErr := updateHighKey(parentNode, currentNodeBreadcrumb.index, currentNodeBreadcrumb.pagedNode)
+	return copied || highKeyChanged, updateErr
+}
+
+// rebalance merges the underflowing node with a sibling when they fit into a
+// single node, otherwise it borrows keys from the sibling
+func rebalance(pager *pg.Pager, currentNodeBreadcrumb *breadcrumb, parentNode *node.InternalNode) error {
+	// prefer the left sibling, the right most node only has one on the left
+	leftIndex := currentNodeBreadcrumb.index
+	if leftIndex > 0 {
+		leftIndex -= 1
+	}
+
+	left, right, readErr := readSiblings(pager, currentNodeBreadcrumb, parentNode, leftIndex)
+	if readErr != nil {
+		return readErr
+	}
+
+	canMerge, mergeErr := mergeOrBorrow(left.Node, right.Node, left == currentNodeBreadcrumb.pagedNode)
+	if mergeErr != nil {
+		return mergeErr
+	}
+
+	if canMerge {
+		// the merged node takes over the key of the right node
+		rightKeyRef, rightKeyRefErr := parentNode.GetKeyPageRefByIndex(leftIndex + 1)
+		if rightKeyRefErr != nil {
+			return rightKeyRefErr
+		}
+
+		if err := parentNode.DeleteAtIndex(leftIndex + 1); err != nil {
+			return err
+		}
+
+		if _, err := parentNode.UpdateAtIndex(leftIndex, rightKeyRef.Key, left.Page); err != nil {
+			return err
+		}
+
+		if _, writeErr := writeNode(pager, left, parentNode, leftIndex); writeErr != nil {
+			return writeErr
+		}
+
+		if err := pager.FreePage(right.Page); err != nil {
+			return err
+		}
+
+		_, updateErr := updateHighKey(parentNode, leftIndex, left)
+		return updateErr
+	}
+
+	for index, pagedNode := range []*pg.PagedNode{left, right} {
+		if _, writeErr := writeNode(pager, pagedNode, parentNode, leftIndex+uint32(index)); writeErr != nil {
+			return writeErr
+		}
+
+		if _, updateErr := updateHighKey(parentNode, leftIndex+uint32(index), pagedNode); updateErr != nil {
+			return updateErr
+		}
+	}
+
+	return nil
+}
+
+func readSiblings(pager *pg.Pager, currentNodeBreadcrumb *breadcrumb, parentNode *node.InternalNode, leftIndex uint32) (*pg.PagedNode, *pg.PagedNode, error) {
+	siblingIndex := leftIndex
+	if siblingIndex == currentNodeBreadcrumb.index {
+		siblingIndex += 1
+	}
+
+	siblingKeyRef, siblingKeyRefErr := parentNode.GetKeyPageRefByIndex(siblingIndex)
+	if siblingKeyRefErr != nil {
+		return nil, nil, siblingKeyRefErr
+	}
+
+	sibling, readErr := pager.ReadPagedNode(siblingKeyRef.PageId)
+	if readErr != nil {
+		return nil, nil, readErr
+	}
+
+	if siblingIndex < currentNodeBreadcrumb.index {
+		return sibling, currentNodeBreadcrumb.pagedNode, nil
+	}
+
+	return currentNodeBreadcrumb.pagedNode, sibling, nil
+}
+
+// mergeOrBorrow merges the right node into the left one if possible, otherwise
+// the underflowing node borrows keys from its sibling
+func mergeOrBorrow(left node.Node, right node.Node, leftIsUnderflowing bool) (bool, error) {
+	switch leftNode := left.(type) {
+	case *node.LeafNode:
+		rightNode, rightOk := right.(*node.LeafNode)
+		if !rightOk {
+			return false, errors.New("failed to cast sibling to leaf node")
+		}
+
+		if leftNode.CanMerge(rightNode) {
+			return true, leftNode.Merge(rightNode)
+		}
+
+		if leftIsUnderflowing {
+			return false, leftNode.BorrowFromRight(rightNode)
+		}
+
+		return false, rightNode.BorrowFromLeft(leftNode)
+	case *node.InternalNode:
+		rightNode, rightOk := right.(*node.InternalNode)
+		if !rightOk {
+			return false, errors.New("failed to cast sibling to internal node")
+		}
+
+		if leftNode.CanMerge(rightNode) {
+			return true, leftNode.Merge(rightNode)
+		}
+
+		if leftIsUnderflowing {
+			return false, leftNode.BorrowFromRight(rightNode)
+		}
+
+		return false, rightNode.BorrowFromLeft(leftNode)
+	default:
+		return false, fmt.Errorf("unexpected node type: %T", left)
+	}
+}
+
+// updateHighKey lowers the key of the child in the parent node to the highest
+// key remaining in the child, it reports whether the parent was modified
+func updateHighKey(parentNode *node.InternalNode, index uint32, child *pg.PagedNode) (bool, error) {
+	if child.Node.GetElementsCount() == 0 {
+		return false, nil
+	}
+
+	maxKey, maxKeyErr := child.Node.GetMaxKey()
+	if maxKeyErr != nil {
+		return false, maxKeyErr
+	}
+
+	keyRef, keyRefErr := parentNode.GetKeyPageRefByIndex(index)
+	if keyRefErr != nil {
+		return false, keyRefErr
+	}
+
+	if bytes.Equal(keyRef.Key, maxKey) {
+		return false, nil
+	}
+
+	// the old key is still a valid upper bound when the lower key does not fit
+	if _, err := parentNode.UpdateAtIndex(index, maxKey, child.Page); err != nil {
+		if errors.Is(err, node.ErrNoAvailableSpaceForInsert) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// handleDeleteInRoot persists the root, a root with a single child is replaced
+// by the child to reduce the height of the tree
+func handleDeleteInRoot(pager *pg.Pager, rootBreadcrumb *breadcrumb) error {
+	root := rootBreadcrumb.pagedNode
+	for root.GetNodeType() == node.InternalNodeType && root.Node.GetElementsCount() == 1 {
+		keyRef, keyRefErr := root.Node.GetKeyRefeferenceByIndex(0)
+		if keyRefErr != nil {
+			return keyRefErr
+		}
+
+		keyPageRef, keyPageRefOk := keyRef.(*node.KeyPageReference)
+		if !keyPageRefOk {
+			return errors.New("failed to cast key reference to key page reference")
+		}
+
+		child, readErr := pager.ReadPagedNode(keyPageRef.PageId)
+		if readErr != nil {
+			return readErr
+		}
+
+		if err := pager.UpdateRootPage(child.Page); err != nil {
+			return err
+		}
+
+		if err := pager.FreePage(root.Page); err != nil {
+			return err
+		}
+
+		root = child
+	}
+
+	if root != rootBreadcrumb.pagedNode {
+		return nil
+	}
+
+	_, writeErr := writeNode(pager, root, nil, 0)
+	return writeErr
+}
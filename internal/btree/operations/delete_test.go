@@ -0,0 +1,192 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/pager"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// checkSubtree validates that all keys of the subtree are ordered, lower than
+// or equal to the high key and greater than the low key
+func checkSubtree(t *testing.T, p *pager.Pager, pageId uint32, lowKey *uint32, highKey *uint32, isRoot bool) []uint32 {
+	pagedNode, readErr := p.ReadPagedNode(pageId)
+	assert.NoError(t, readErr)
+
+	count := pagedNode.Node.GetElementsCount()
+	if !isRoot {
+		assert.NotZero(t, count, "page %d is empty", pageId)
+	}
+
+	keys := []uint32{}
+	previousKey := lowKey
+	for index := uint32(0); index < count; index++ {
+		keyRef, keyRefErr := pagedNode.Node.GetKeyRefeferenceByIndex(index)
+		assert.NoError(t, keyRefErr)
+		key := uint32FromKey(keyRef.GetKey())
+
+		if previousKey != nil {
+			assert.Greater(t, key, *previousKey, "page %d is not ordered", pageId)
+		}
+		if highKey != nil {
+			assert.LessOrEqual(t, key, *highKey, "page %d exceeds its high key", pageId)
+		}
+
+		if internalKeyRef, ok := keyRef.(*node.KeyPageReference); ok {
+			keys = append(keys, checkSubtree(t, p, internalKeyRef.PageId, previousKey, &key, false)...)
+		} else {
+			keys = append(keys, key)
+		}
+
+		previousKey = &key
+	}
+
+	return keys
+}
+
+func checkTree(t *testing.T, p *pager.Pager) []uint32 {
+	root, readErr := p.ReadRootNode()
+	assert.NoError(t, readErr)
+
+	return checkSubtree(t, p, root.Page, nil, nil, true)
+}
+
+func TestDeleteOperation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "delete.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	for key := uint32(0); key < 3; key++ {
+		insertErr := Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key)))
+		assert.NoError(t, insertErr)
+	}
+
+	deleteErr := Delete(pager, uint32Key(1))
+	assert.NoError(t, deleteErr)
+
+	_, getErr := Get(pager, uint32Key(1))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+
+	data, get2Err := Get(pager, uint32Key(2))
+	assert.NoError(t, get2Err)
+	assert.Equal(t, []byte("data2"), data)
+
+	assert.ErrorIs(t, Delete(pager, uint32Key(1)), ErrKeyNotFound)
+	assert.Equal(t, []uint32{0, 2}, checkTree(t, pager))
+}
+
+func TestDeleteOperationRebalancesTree(t *testing.T) {
+	orders := map[string]func(keys []uint32){
+		"ascending": func(keys []uint32) {},
+		"descending": func(keys []uint32) {
+			for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+				keys[i], keys[j] = keys[j], keys[i]
+			}
+		},
+		"random": func(keys []uint32) {
+			random := rand.New(rand.NewSource(42))
+			random.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		},
+	}
+
+	for name, order := range orders {
+		t.Run(name, func(t *testing.T) {
+			tempDir := os.TempDir()
+			dbFileName := tempDir + "delete.db"
+			defer os.Remove(dbFileName)
+
+			pager := newCursorTestPager(t, dbFileName, keysInRange(0, 2000))
+
+			keys := keysInRange(0, 2000)
+			order(keys)
+
+			remaining := map[uint32]bool{}
+			for _, key := range keys {
+				remaining[key] = true
+			}
+
+			for i, key := range keys {
+				assert.NoError(t, Delete(pager, uint32Key(key)))
+				delete(remaining, key)
+
+				if i%250 == 0 {
+					treeKeys := checkTree(t, pager)
+					assert.Len(t, treeKeys, len(remaining))
+					for _, treeKey := range treeKeys {
+						assert.True(t, remaining[treeKey])
+					}
+				}
+			}
+
+			root, readErr := pager.ReadRootNode()
+			assert.NoError(t, readErr)
+			assert.Equal(t, node.LeafNodeType, root.GetNodeType())
+			assert.Zero(t, root.Node.GetElementsCount())
+
+			// the emptied tree accepts new keys
+			assert.NoError(t, Insert(pager, uint32Key(7), []byte("data7")))
+			assert.Equal(t, []uint32{7}, checkTree(t, pager))
+		})
+	}
+}
+
+func TestDeleteOperationKeepsRemainingKeysReadable(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "delete.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 1000))
+
+	for key := uint32(0); key < 1000; key += 3 {
+		assert.NoError(t, Delete(pager, uint32Key(key)))
+	}
+
+	for key := uint32(0); key < 1000; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		if key%3 == 0 {
+			assert.ErrorIs(t, getErr, ErrKeyNotFound)
+		} else {
+			assert.NoError(t, getErr)
+			assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+		}
+	}
+
+	cursor := NewCursor(pager, nil)
+	count := 0
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		assert.NotZero(t, uint32FromKey(cursor.Key())%3)
+		count += 1
+	}
+	assert.Equal(t, 666, count)
+}
+
+func TestDeleteOperationReusesFreedPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "delete.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 2000))
+	pageCount := pager.PageCount()
+
+	for key := uint32(0); key < 2000; key++ {
+		assert.NoError(t, Delete(pager, uint32Key(key)))
+	}
+	assert.Equal(t, pageCount-1, pager.FreePageCount())
+
+	for key := uint32(0); key < 2000; key++ {
+		assert.NoError(t, Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key))))
+	}
+
+	assert.Equal(t, pageCount, pager.PageCount())
+	assert.Equal(t, keysInRange(0, 2000), checkTree(t, pager))
+}
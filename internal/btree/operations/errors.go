@@ -0,0 +1,5 @@
+package operations
+
+import "errors"
+
+var ErrKeyNotFound = errors.New("key not found")
@@ -0,0 +1,82 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"fmt"
+	"io"
+)
+
+func Get(pager *pg.Pager, key []byte) ([]byte, error) {
+	leaf, keyRef, findErr := findEntry(pager, key)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	return readValue(pager, leaf, keyRef)
+}
+
+// OpenValue returns a reader over the value of the key which reads the
+// overflow pages of large values on demand. The reader is only valid until
+// the key is written again.
+func OpenValue(pager *pg.Pager, key []byte) (io.ReadSeeker, error) {
+	leaf, keyRef, findErr := findEntry(pager, key)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	return valueReader(pager, leaf, keyRef)
+}
+
+// GetLatest returns the value of the key committed last. The path to the
+// leaf is latched like by the writers, the pager of a writer can read while
+// other writers change the tree.
+func GetLatest(pager *pg.Pager, key []byte) ([]byte, error) {
+	latches := newPathLatches(pager)
+	defer latches.release()
+
+	breadcrumbs, searchErr := findPositionForRead(pager, latches, key)
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	leaf, keyRef, findErr := findLeafEntry(pager, breadcrumbs, key)
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	return readValue(pager, leaf, keyRef)
+}
+
+func findEntry(pager *pg.Pager, key []byte) (*node.LeafNode, *node.KeyDataReference, error) {
+	breadcrumbs, searchErr := findPosition(pager, key)
+	if searchErr != nil {
+		return nil, nil, searchErr
+	}
+
+	return findLeafEntry(pager, breadcrumbs, key)
+}
+
+func findLeafEntry(pager *pg.Pager, breadcrumbs []*breadcrumb, key []byte) (*node.LeafNode, *node.KeyDataReference, error) {
+	leafBreadcrumb := breadcrumbs[len(breadcrumbs)-1]
+	leaf, leafOk := leafBreadcrumb.pagedNode.Node.(*node.LeafNode)
+	if !leafOk {
+		return nil, nil, fmt.Errorf("unable to cast to leaf node")
+	}
+
+	exists, index, findErr := node.FindPositionForKey(leaf, key, pager.Comparator())
+	if findErr != nil {
+		return nil, nil, fmt.Errorf("failed to find position of key %q: %w", key, findErr)
+	}
+
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	keyRef, keyRefErr := leaf.GetKeyDataRefByIndex(index)
+	if keyRefErr != nil {
+		return nil, nil, keyRefErr
+	}
+
+	return leaf, keyRef, nil
+}
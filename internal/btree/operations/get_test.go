@@ -0,0 +1,134 @@
+package operations
+
+import (
+	"bricker-db/internal/pager"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOperation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "get.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := initRootNode(pager, 350)
+	assert.NoError(t, initErr)
+
+	keys := []uint32{2, 0, 1, 3}
+	for _, key := range keys {
+		insertErr := Insert(pager, uint32Key(key), []byte(fmt.Sprintf("key%dData", key)))
+		assert.NoError(t, insertErr)
+	}
+
+	for _, key := range keys {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("key%dData", key)), data)
+	}
+}
+
+func TestGetOperationMissingKey(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "get.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	_, emptyGetErr := Get(pager, uint32Key(1))
+	assert.ErrorIs(t, emptyGetErr, ErrKeyNotFound)
+
+	insertErr := Insert(pager, uint32Key(1), []byte("data"))
+	assert.NoError(t, insertErr)
+
+	_, getErr := Get(pager, uint32Key(2))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+}
+
+func TestGetOperationAcrossSplits(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "get.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	for key := uint32(0); key < 500; key++ {
+		insertErr := Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key)))
+		assert.NoError(t, insertErr)
+	}
+
+	for key := uint32(0); key < 500; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+	}
+}
+
+func TestGetOperationReturnsCopy(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "get.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	insertErr := Insert(pager, uint32Key(1), []byte("data"))
+	assert.NoError(t, insertErr)
+
+	data, getErr := Get(pager, uint32Key(1))
+	assert.NoError(t, getErr)
+	data[0] = 'x'
+
+	dataAgain, getAgainErr := Get(pager, uint32Key(1))
+	assert.NoError(t, getAgainErr)
+	assert.Equal(t, []byte("data"), dataAgain)
+}
+
+func TestGetLatestWithConcurrentWriters(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "get_latest.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 500))
+	defer pager.CloseFile()
+
+	// the writers split the leaves the readers descend to
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer := pager.NewWriter()
+		for key := uint32(500); key < 1000; key++ {
+			assert.NoError(t, Upsert(writer, uint32Key(key), []byte(fmt.Sprintf("data%d", key))))
+		}
+	}()
+
+	reader := pager.NewWriter()
+	for round := 0; round < 3; round++ {
+		for key := uint32(0); key < 500; key++ {
+			data, getErr := GetLatest(reader, uint32Key(key))
+			assert.NoError(t, getErr)
+			assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+		}
+	}
+	<-done
+
+	data, getErr := GetLatest(reader, uint32Key(999))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("data999"), data)
+}
@@ -1,8 +1,8 @@
 package operations
 
 import (
-	"bricker-db/btree/node"
-	pg "bricker-db/pager"
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
 )
 
 func Init(pager *pg.Pager) error {
@@ -10,7 +10,7 @@ func Init(pager *pg.Pager) error {
 		return nil
 	}
 
-	return initRootNode(pager, node.LEAF_NODE_SIZE)
+	return initRootNode(pager, pager.NodeSize())
 }
 
 func initRootNode(pager *pg.Pager, size uint32) error {
@@ -0,0 +1,52 @@
+package operations
+
+import (
+	"bricker-db/internal/pager"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitOperationCreatesRootNode(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	assert.False(t, pager.RootNodeInitialized())
+	_, readUninitializedRootErr := pager.ReadRootNode()
+	assert.Error(t, readUninitializedRootErr)
+
+	initErr := Init(pager)
+	assert.NoError(t, initErr)
+
+	rootNode, readRootErr := pager.ReadRootNode()
+	assert.NoError(t, readRootErr)
+	assert.Equal(t, uint32(0), rootNode.Page)
+}
+
+func TestInitOperationKeepsTreeOfReopenedFile(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	p := newCursorTestPager(t, dbFileName, keysInRange(0, 2000))
+	rootNode, readRootErr := p.ReadRootNode()
+	assert.NoError(t, readRootErr)
+	assert.NotEqual(t, uint32(0), rootNode.Page)
+	assert.NoError(t, p.CloseFile())
+
+	reopened, reopenErr := pager.NewPager(dbFileName)
+	assert.NoError(t, reopenErr)
+	defer reopened.CloseFile()
+
+	assert.True(t, reopened.RootNodeInitialized())
+	assert.NoError(t, Init(reopened))
+	reopenedRoot, readReopenedRootErr := reopened.ReadRootNode()
+	assert.NoError(t, readReopenedRootErr)
+	assert.Equal(t, rootNode.Page, reopenedRoot.Page)
+	assert.Equal(t, keysInRange(0, 2000), checkTree(t, reopened))
+}
@@ -1,21 +1,35 @@
 package operations
 
 import (
-	"bricker-db/btree/node"
-	pg "bricker-db/pager"
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
 	"errors"
 	"fmt"
 )
 
-type Breadcrumb struct {
+type breadcrumb struct {
 	pagedNode       *pg.PagedNode
 	index           uint32
-	key             uint32
+	key             []byte
 	isRightMostNode bool
 }
 
-func Insert(pager *pg.Pager, key uint32, data []byte) error {
-	breadcrumbs, searchErr := findPosition(pager, key)
+func Insert(pager *pg.Pager, key []byte, data []byte) error {
+	if len(key) > node.MAX_KEY_SIZE {
+		return node.ErrKeyTooLarge
+	}
+
+	// the latches are released once the insert is committed
+	latches := newPathLatches(pager)
+	defer latches.release()
+
+	return pager.Atomic(func() error {
+		return insertKey(pager, latches, key, data)
+	})
+}
+
+func insertKey(pager *pg.Pager, latches *pathLatches, key []byte, data []byte) error {
+	breadcrumbs, searchErr := findPositionForWrite(pager, latches, key, data, nil)
 	if searchErr != nil {
 		return searchErr
 	}
@@ -26,19 +40,15 @@ func Insert(pager *pg.Pager, key uint32, data []byte) error {
 		return fmt.Errorf("unable to cast to leaf node")
 	}
 
-	insertResult, insertErr := leaf.Insert(key, data)
+	insertResult, insertErr := insertValue(pager, leaf, key, data)
 	if insertErr != nil {
 		return insertErr
 	}
 
-	if writeErr := pager.WritePagedNode(leafBreadcrumb.pagedNode); writeErr != nil {
-		return writeErr
-	}
-
 	return propagateInsertUpdates(pager, insertResult.Metadata, breadcrumbs)
 }
 
-func findPosition(pager *pg.Pager, key uint32) ([]*Breadcrumb, error) {
+func findPosition(pager *pg.Pager, key []byte) ([]*breadcrumb, error) {
 	rootPagedNode, rootNodeErr := pager.ReadRootNode()
 	if rootNodeErr != nil {
 		return nil, fmt.Errorf("failed to read root node: %w", rootNodeErr)
@@ -46,8 +56,8 @@ func findPosition(pager *pg.Pager, key uint32) ([]*Breadcrumb, error) {
 
 	var currentNode *pg.PagedNode
 	currentNode = rootPagedNode
-	var breadcrumbs []*Breadcrumb
-	breadcrumbs = append(breadcrumbs, &Breadcrumb{currentNode, 0, 0, true})
+	var breadcrumbs []*breadcrumb
+	breadcrumbs = append(breadcrumbs, &breadcrumb{currentNode, 0, nil, true})
 	for {
 		if currentNode.GetNodeType() == node.LeafNodeType {
 			return breadcrumbs, nil
@@ -57,9 +67,9 @@ func findPosition(pager *pg.Pager, key uint32) ([]*Breadcrumb, error) {
 				return nil, fmt.Errorf("failed to cast internal node")
 			}
 
-			index, keyRef, findErr := internalNode.FindPositionForKey(key)
+			index, keyRef, findErr := internalNode.FindPositionForKey(key, pager.Comparator())
 			if findErr != nil {
-				return nil, fmt.Errorf("failed to find position for %d: %w", key, findErr)
+				return nil, fmt.Errorf("failed to find position for %q: %w", key, findErr)
 			}
 
 			pagedNode, readErr := pager.ReadPagedNode(keyRef.PageId)
@@ -69,12 +79,12 @@ func findPosition(pager *pg.Pager, key uint32) ([]*Breadcrumb, error) {
 
 			isRightMostNode := index == currentNode.Node.GetElementsCount()-1
 			currentNode = pagedNode
-			breadcrumbs = append(breadcrumbs, &Breadcrumb{currentNode, index, keyRef.GetKey(), isRightMostNode})
+			breadcrumbs = append(breadcrumbs, &breadcrumb{currentNode, index, keyRef.GetKey(), isRightMostNode})
 		}
 	}
 }
 
-func handleSplit(pager *pg.Pager, split *node.SplitMetadata, currentNodeBreadcrumb *Breadcrumb, parentNodeBreadcrumb *Breadcrumb) (*node.InsertMetadata, error) {
+func handleSplit(pager *pg.Pager, split *node.SplitMetadata, currentNodeBreadcrumb *breadcrumb, parentNodeBreadcrumb *breadcrumb) (*node.InsertMetadata, error) {
 	// flush the created node
 	newPagedNode, writeErr := pager.WriteNewNode(split.CreatedNode)
 	if writeErr != nil {
@@ -89,19 +99,17 @@ func handleSplit(pager *pg.Pager, split *node.SplitMetadata, currentNodeBreadcru
 	if parentNodeBreadcrumb == nil {
 		// if no parent then we are splitting root
 		// create new root node
-		fmt.Println("inserting into new root")
-		newRoot := node.NewEmptyInternalNode(node.INTERNAL_NODE_SIZE)
-		_, insert1Err := newRoot.Insert(split.SplitKey, currentNodeBreadcrumb.pagedNode.Page)
+		newRoot := node.NewEmptyInternalNode(pager.NodeSize())
+		_, insert1Err := newRoot.Insert(split.SplitKey, currentNodeBreadcrumb.pagedNode.Page, pager.Comparator())
 		if insert1Err != nil {
 			return nil, insert1Err
 		}
 
-		_, insert2Err := newRoot.Insert(maxKey, newPagedNode.Page)
+		_, insert2Err := newRoot.Insert(maxKey, newPagedNode.Page, pager.Comparator())
 		if insert2Err != nil {
 			return nil, insert2Err
 		}
 
-		fmt.Println("inserting into new root")
 		_, writeRootErr := pager.WriteNewRootNode(newRoot)
 		return nil, writeRootErr
 
@@ -123,7 +131,7 @@ func handleSplit(pager *pg.Pager, split *node.SplitMetadata, currentNodeBreadcru
 		}
 
 		// add new divider
-		insertResult, insertErr = parentNode.Insert(split.SplitKey, currentNodeBreadcrumb.pagedNode.Page)
+		insertResult, insertErr = parentNode.Insert(split.SplitKey, currentNodeBreadcrumb.pagedNode.Page, pager.Comparator())
 	} else {
 		// update old key ref key and point to the old node
 		_, updateErr := parentNode.UpdateAtIndex(currentNodeBreadcrumb.index, split.SplitKey, currentNodeBreadcrumb.pagedNode.Page)
@@ -132,21 +140,17 @@ func handleSplit(pager *pg.Pager, split *node.SplitMetadata, currentNodeBreadcru
 		}
 
 		// add new divider
-		insertResult, insertErr = parentNode.Insert(maxKey, newPagedNode.Page)
+		insertResult, insertErr = parentNode.Insert(maxKey, newPagedNode.Page, pager.Comparator())
 	}
 
 	if insertErr != nil {
 		return nil, insertErr
 	}
 
-	// persist changes
-	if writeErr := pager.WritePagedNode(parentNodeBreadcrumb.pagedNode); writeErr != nil {
-		return nil, writeErr
-	}
 	return insertResult.Metadata, nil
 }
 
-func handleNewHighKeyInserted(pager *pg.Pager, update *node.HighKeyUpdate, currentNodeBreadcrumb *Breadcrumb, parentNodeBreadcrumb *Breadcrumb) (*node.InsertMetadata, error) {
+func handleNewHighKeyInserted(pager *pg.Pager, update *node.HighKeyUpdate, currentNodeBreadcrumb *breadcrumb, parentNodeBreadcrumb *breadcrumb) (*node.InsertMetadata, error) {
 	// if there is no parent we don't need to propagate any changes
 	if parentNodeBreadcrumb == nil {
 		return nil, nil
@@ -167,51 +171,50 @@ func handleNewHighKeyInserted(pager *pg.Pager, update *node.HighKeyUpdate, curre
 		return nil, updateErr
 	}
 
-	// persist changes
-	if writeErr := pager.WritePagedNode(parentNodeBreadcrumb.pagedNode); writeErr != nil {
-		return nil, writeErr
-	}
-
 	return &node.InsertMetadata{Split: nil, HighKey: parentHighKeyUpdate}, nil
 }
 
-func propagateInsertUpdates(pager *pg.Pager, metadata *node.InsertMetadata, breadscrumbs []*Breadcrumb) error {
+// propagateInsertUpdates persists the changed nodes from the leaf towards
+// the root, it stops at the first node whose parent did not change
+func propagateInsertUpdates(pager *pg.Pager, metadata *node.InsertMetadata, breadscrumbs []*breadcrumb) error {
 	insertMetadata := metadata
-	breadcrumbsIndex := len(breadscrumbs) - 1
+	for level := len(breadscrumbs) - 1; level >= 0; level-- {
+		currentNodeBreadcrumb := breadscrumbs[level]
+		parentNodeBreadcrumb := getBreadcrumb(level-1, breadscrumbs)
 
-	for true {
-		if insertMetadata == nil {
-			// done
-			return nil
+		parentChanged, writeErr := writeBreadcrumb(pager, breadscrumbs, level)
+		if writeErr != nil {
+			return writeErr
 		}
 
-		currentNodeBreadcrumb := getBreadcrumb(breadcrumbsIndex, breadscrumbs)
-		parentNodeBreadcrumb := getBreadcrumb(breadcrumbsIndex-1, breadscrumbs)
-		breadcrumbsIndex -= 1
-
-		if insertMetadata.Split != nil {
+		var parentMetadata *node.InsertMetadata
+		if insertMetadata != nil && insertMetadata.Split != nil {
 			var splitErr error
-			insertMetadata, splitErr = handleSplit(pager, insertMetadata.Split, currentNodeBreadcrumb, parentNodeBreadcrumb)
+			parentMetadata, splitErr = handleSplit(pager, insertMetadata.Split, currentNodeBreadcrumb, parentNodeBreadcrumb)
 			if splitErr != nil {
 				return splitErr
 			}
-		} else if insertMetadata.HighKey != nil {
+			parentChanged = true
+		} else if insertMetadata != nil && insertMetadata.HighKey != nil {
 			var highKeyUpdateErr error
-			insertMetadata, highKeyUpdateErr = handleNewHighKeyInserted(pager, insertMetadata.HighKey, currentNodeBreadcrumb, parentNodeBreadcrumb)
+			parentMetadata, highKeyUpdateErr = handleNewHighKeyInserted(pager, insertMetadata.HighKey, currentNodeBreadcrumb, parentNodeBreadcrumb)
 			if highKeyUpdateErr != nil {
 				return highKeyUpdateErr
 			}
-		} else {
+			parentChanged = parentChanged || parentMetadata != nil
+		}
+
+		if !parentChanged || parentNodeBreadcrumb == nil {
 			// done
 			return nil
 		}
-
+		insertMetadata = parentMetadata
 	}
 
 	return nil
 }
 
-func getBreadcrumb(index int, breadscrumbs []*Breadcrumb) *Breadcrumb {
+func getBreadcrumb(index int, breadscrumbs []*breadcrumb) *breadcrumb {
 	if index < len(breadscrumbs) && index >= 0 {
 		return breadscrumbs[index]
 	}
@@ -0,0 +1,215 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/pager"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uint32Key encodes the key big endian so the byte order matches the
+// numeric order
+func uint32Key(key uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, key)
+}
+
+func uint32FromKey(key []byte) uint32 {
+	return binary.BigEndian.Uint32(key)
+}
+
+func TestInsertOperation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	initErr := initRootNode(pager, 70)
+	assert.NoError(t, initErr)
+
+	key1 := uint32(2)
+	key1Data := []byte("key1Data")
+
+	key2 := uint32(0)
+	key2Data := []byte("key2Data")
+
+	key3 := uint32(1)
+	key3Data := []byte("key3Data")
+
+	key4 := uint32(3)
+	key4Data := []byte("key4Data")
+
+	insert1Err := Insert(pager, uint32Key(key1), key1Data)
+	assert.NoError(t, insert1Err)
+
+	insert2Err := Insert(pager, uint32Key(key2), key2Data)
+	assert.NoError(t, insert2Err)
+
+	insert3Err := Insert(pager, uint32Key(key3), key3Data)
+	assert.NoError(t, insert3Err)
+
+	insert4Err := Insert(pager, uint32Key(key4), key4Data)
+	assert.NoError(t, insert4Err)
+
+	pagedRoot, readErr := pager.ReadRootNode()
+	assert.NoError(t, readErr)
+
+	root, rootOk := pagedRoot.Node.(*node.InternalNode)
+	assert.True(t, rootOk)
+
+	assert.Equal(t, uint32(2), root.GetElementsCount())
+
+	keyRef1, keyRef1Err := root.GetKeyPageRefByIndex(0)
+	assert.NoError(t, keyRef1Err)
+	assert.Equal(t, uint32Key(1), keyRef1.Key)
+
+	keyRef2, keyRef2Err := root.GetKeyPageRefByIndex(1)
+	assert.NoError(t, keyRef2Err)
+	assert.Equal(t, uint32Key(3), keyRef2.Key)
+
+	// the leaves are copied on each write, the pages they replace are free
+	for _, keyRef := range []*node.KeyPageReference{keyRef1, keyRef2} {
+		child, childErr := pager.ReadPagedNode(keyRef.PageId)
+		assert.NoError(t, childErr)
+		assert.Equal(t, node.LeafNodeType, child.GetNodeType())
+		assert.Equal(t, uint32(2), child.Node.GetElementsCount())
+	}
+	assert.Equal(t, uint32(3), pager.PageCount()-pager.FreePageCount())
+}
+
+func TestInsertOperationWithVariableLengthKeys(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "variable_keys.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, Init(pager))
+
+	keys := make([][]byte, 0, 500)
+	for i := 0; i < 500; i++ {
+		// keys of different lengths force splits with uneven separators
+		key := []byte(fmt.Sprintf("user:%d:%s", i, strings.Repeat("x", i%50)))
+		keys = append(keys, key)
+		assert.NoError(t, Insert(pager, key, []byte(fmt.Sprintf("data%d", i))))
+	}
+
+	for i, key := range keys {
+		data, getErr := Get(pager, key)
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", i)), data)
+	}
+
+	// cursor returns the keys in lexicographic order
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	cursor := NewCursor(pager, nil)
+	var iterated [][]byte
+	for valid := cursor.First(); valid; valid = cursor.Next() {
+		iterated = append(iterated, cursor.Key())
+	}
+	assert.NoError(t, cursor.Err())
+	assert.Equal(t, keys, iterated)
+}
+
+func TestInsertOperationKeyTooLarge(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "key_too_large.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, Init(pager))
+
+	assert.NoError(t, Insert(pager, bytes.Repeat([]byte{1}, node.MAX_KEY_SIZE), []byte("data")))
+	assert.ErrorIs(t, Insert(pager, bytes.Repeat([]byte{2}, node.MAX_KEY_SIZE+1), []byte("data")), node.ErrKeyTooLarge)
+}
+
+func TestInsertOperationDescendingKeys(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "descending_keys.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, Init(pager))
+
+	// every key lands in the first leaf, which is split over and over again
+	for key := uint32(1000); key > 0; key-- {
+		assert.NoError(t, Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key))))
+	}
+
+	for key := uint32(1); key <= 1000; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+	}
+}
+
+func TestInsertOperationWithLargePages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "insert.db"
+	defer os.Remove(dbFileName)
+
+	options := pager.DefaultOptions()
+	options.PageSize = pager.MAX_PAGE_SIZE
+	p, pagerErr := pager.NewPagerWithOptions(dbFileName, options)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, Init(p))
+
+	for key := uint32(0); key < 5000; key++ {
+		assert.NoError(t, Insert(p, uint32Key(key), []byte(fmt.Sprintf("data%d", key))))
+	}
+	assert.Equal(t, keysInRange(0, 5000), checkTree(t, p))
+
+	// values stay in the leaf up to a third of the larger node
+	assert.NoError(t, Update(p, uint32Key(7), bytes.Repeat([]byte("v"), 20000)))
+	_, keyRef, findErr := findEntry(p, uint32Key(7))
+	assert.NoError(t, findErr)
+	assert.False(t, keyRef.Overflow)
+	data, getErr := Get(p, uint32Key(7))
+	assert.NoError(t, getErr)
+	assert.Equal(t, bytes.Repeat([]byte("v"), 20000), data)
+}
+
+func TestInsertOperationConcurrentWriters(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "insert.db"
+	defer os.Remove(dbFileName)
+
+	p, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	defer p.CloseFile()
+	assert.NoError(t, Init(p))
+
+	// every writer inserts its own range of keys, the values fill a leaf
+	// after a few dozen keys
+	const writers = 8
+	const keysPerWriter = 400
+	var wg sync.WaitGroup
+	for w := uint32(0); w < writers; w++ {
+		wg.Add(1)
+		go func(writer *pager.Pager, start uint32) {
+			defer wg.Done()
+			for key := start; key < start+keysPerWriter; key++ {
+				assert.NoError(t, Insert(writer, uint32Key(key), bytes.Repeat([]byte{byte(key)}, 100)))
+			}
+		}(p.NewWriter(), w*keysPerWriter)
+	}
+	wg.Wait()
+
+	assert.Equal(t, keysInRange(0, writers*keysPerWriter), checkTree(t, p))
+	for key := uint32(0); key < writers*keysPerWriter; key++ {
+		data, getErr := Get(p, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, bytes.Repeat([]byte{byte(key)}, 100), data)
+	}
+}
@@ -0,0 +1,229 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"fmt"
+)
+
+// pathLatches holds the latches of a write on the path from the root to the
+// leaf of its key. They are taken top down and the latches above a node are
+// released as soon as the node absorbs the write, the remaining latches are
+// released once the write is committed.
+type pathLatches struct {
+	pager         *pg.Pager
+	root          bool
+	rootExclusive bool
+	pages         []heldLatch
+}
+
+type heldLatch struct {
+	pageId    uint32
+	exclusive bool
+}
+
+func newPathLatches(pager *pg.Pager) *pathLatches {
+	return &pathLatches{pager: pager}
+}
+
+func (l *pathLatches) latchRoot(exclusive bool) {
+	l.pager.LatchRoot(exclusive)
+	l.root = true
+	l.rootExclusive = exclusive
+}
+
+func (l *pathLatches) latchPage(pageId uint32, exclusive bool) {
+	l.pager.LatchPage(pageId, exclusive)
+	l.pages = append(l.pages, heldLatch{pageId, exclusive})
+}
+
+func (l *pathLatches) unlatchLastPage() {
+	last := l.pages[len(l.pages)-1]
+	l.pager.UnlatchPage(last.pageId, last.exclusive)
+	l.pages = l.pages[:len(l.pages)-1]
+}
+
+// releaseAbove releases the root latch and the latches of the pages above
+// the last latched page
+func (l *pathLatches) releaseAbove() {
+	if l.root {
+		l.pager.UnlatchRoot(l.rootExclusive)
+		l.root = false
+	}
+
+	for _, held := range l.pages[:len(l.pages)-1] {
+		l.pager.UnlatchPage(held.pageId, held.exclusive)
+	}
+	l.pages = l.pages[len(l.pages)-1:]
+}
+
+func (l *pathLatches) release() {
+	if len(l.pages) > 0 {
+		l.releaseAbove()
+		l.unlatchLastPage()
+	}
+
+	if l.root {
+		l.pager.UnlatchRoot(l.rootExclusive)
+		l.root = false
+	}
+}
+
+// findPositionForWrite descends to the leaf of the key for a write of the
+// data or of the overflow pointer when it is not nil. The first descent
+// latches the internal nodes shared and the leaf exclusively, when the write
+// changes the parent of the leaf the tree is descended again latching
+// exclusively the nodes which can change.
+func findPositionForWrite(pager *pg.Pager, latches *pathLatches, key []byte, data []byte, pointer *node.OverflowPointer) ([]*breadcrumb, error) {
+	breadcrumbs, absorbed, descendErr := descendForWrite(pager, latches, key, data, pointer, false)
+	if descendErr != nil || absorbed {
+		return breadcrumbs, descendErr
+	}
+
+	latches.release()
+	breadcrumbs, _, descendErr = descendForWrite(pager, latches, key, data, pointer, true)
+	return breadcrumbs, descendErr
+}
+
+// descendForWrite reports whether the leaf absorbs the write, only the leaf
+// stays latched then
+func descendForWrite(pager *pg.Pager, latches *pathLatches, key []byte, data []byte, pointer *node.OverflowPointer, exclusive bool) ([]*breadcrumb, bool, error) {
+	latches.latchRoot(exclusive)
+	rootPageId := pager.RootPageId()
+	latches.latchPage(rootPageId, exclusive)
+
+	rootPagedNode, rootNodeErr := pager.ReadPagedNode(rootPageId)
+	if rootNodeErr != nil {
+		return nil, false, fmt.Errorf("failed to read root node: %w", rootNodeErr)
+	}
+
+	breadcrumbs := []*breadcrumb{{rootPagedNode, 0, nil, true}}
+	for {
+		currentBreadcrumb := breadcrumbs[len(breadcrumbs)-1]
+		isRoot := len(breadcrumbs) == 1
+
+		if currentBreadcrumb.pagedNode.GetNodeType() == node.LeafNodeType {
+			if !exclusive {
+				// the parent is still latched so the leaf can't be split
+				// before it is latched again
+				latches.unlatchLastPage()
+				latches.latchPage(currentBreadcrumb.pagedNode.Page, true)
+
+				leafPagedNode, readErr := pager.ReadPagedNode(currentBreadcrumb.pagedNode.Page)
+				if readErr != nil {
+					return nil, false, readErr
+				}
+				currentBreadcrumb.pagedNode = leafPagedNode
+			}
+
+			absorbed, absorbErr := leafAbsorbsWrite(pager, currentBreadcrumb, isRoot, key, data, pointer)
+			if absorbErr != nil {
+				return nil, false, absorbErr
+			}
+
+			if absorbed {
+				latches.releaseAbove()
+			}
+
+			return breadcrumbs, absorbed, nil
+		}
+
+		internalNode, internalNodeOk := currentBreadcrumb.pagedNode.Node.(*node.InternalNode)
+		if !internalNodeOk {
+			return nil, false, fmt.Errorf("failed to cast internal node")
+		}
+
+		index, keyRef, findErr := internalNode.FindPositionForKey(key, pager.Comparator())
+		if findErr != nil {
+			return nil, false, fmt.Errorf("failed to find position for %q: %w", key, findErr)
+		}
+
+		isRightMostNode := index == internalNode.GetElementsCount()-1
+		if !exclusive || internalAbsorbsWrite(pager, internalNode, currentBreadcrumb, isRoot, isRightMostNode) {
+			latches.releaseAbove()
+		}
+
+		latches.latchPage(keyRef.PageId, exclusive)
+		pagedNode, readErr := pager.ReadPagedNode(keyRef.PageId)
+		if readErr != nil {
+			return nil, false, readErr
+		}
+
+		breadcrumbs = append(breadcrumbs, &breadcrumb{pagedNode, index, keyRef.GetKey(), isRightMostNode})
+	}
+}
+
+// findPositionForRead descends to the leaf of the key latching the nodes
+// shared, the latch of a node is released once its child is latched
+func findPositionForRead(pager *pg.Pager, latches *pathLatches, key []byte) ([]*breadcrumb, error) {
+	latches.latchRoot(false)
+	rootPageId := pager.RootPageId()
+	latches.latchPage(rootPageId, false)
+	latches.releaseAbove()
+
+	rootPagedNode, rootNodeErr := pager.ReadPagedNode(rootPageId)
+	if rootNodeErr != nil {
+		return nil, fmt.Errorf("failed to read root node: %w", rootNodeErr)
+	}
+
+	breadcrumbs := []*breadcrumb{{rootPagedNode, 0, nil, true}}
+	for {
+		currentBreadcrumb := breadcrumbs[len(breadcrumbs)-1]
+		if currentBreadcrumb.pagedNode.GetNodeType() == node.LeafNodeType {
+			return breadcrumbs, nil
+		}
+
+		internalNode, internalNodeOk := currentBreadcrumb.pagedNode.Node.(*node.InternalNode)
+		if !internalNodeOk {
+			return nil, fmt.Errorf("failed to cast internal node")
+		}
+
+		index, keyRef, findErr := internalNode.FindPositionForKey(key, pager.Comparator())
+		if findErr != nil {
+			return nil, fmt.Errorf("failed to find position for %q: %w", key, findErr)
+		}
+
+		latches.latchPage(keyRef.PageId, false)
+		latches.releaseAbove()
+		pagedNode, readErr := pager.ReadPagedNode(keyRef.PageId)
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		isRightMostNode := index == internalNode.GetElementsCount()-1
+		breadcrumbs = append(breadcrumbs, &breadcrumb{pagedNode, index, keyRef.GetKey(), isRightMostNode})
+	}
+}
+
+// leafAbsorbsWrite reports whether the write leaves the parent of the leaf
+// unchanged, the leaf must not split nor be copied and the parent only
+// follows the high key of its right most child
+func leafAbsorbsWrite(pager *pg.Pager, leafBreadcrumb *breadcrumb, isRoot bool, key []byte, data []byte, pointer *node.OverflowPointer) (bool, error) {
+	if !pager.CanOverwrite(leafBreadcrumb.pagedNode.Page) {
+		return false, nil
+	}
+
+	leaf, leafOk := leafBreadcrumb.pagedNode.Node.(*node.LeafNode)
+	if !leafOk {
+		return false, fmt.Errorf("unable to cast to leaf node")
+	}
+
+	overflow := pointer != nil || leaf.NeedsOverflow(key, data)
+	splits, newHighKey, effectsErr := leaf.WriteEffects(key, data, overflow, pager.Comparator())
+	if effectsErr != nil {
+		return false, effectsErr
+	}
+
+	return !splits && (!newHighKey || isRoot || !leafBreadcrumb.isRightMostNode), nil
+}
+
+// internalAbsorbsWrite reports whether the changes of the child leave the
+// parent of the node unchanged, the high key of the node only changes with
+// its right most child and a node which is copied changes its parent
+func internalAbsorbsWrite(pager *pg.Pager, internalNode *node.InternalNode, currentBreadcrumb *breadcrumb, isRoot bool, childIsRightMost bool) bool {
+	if !internalNode.CanAbsorbChildSplit() || !pager.CanOverwrite(currentBreadcrumb.pagedNode.Page) {
+		return false
+	}
+
+	return isRoot || !currentBreadcrumb.isRightMostNode || !childIsRightMost
+}
@@ -0,0 +1,72 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"fmt"
+)
+
+// Migrate rewrites the nodes stored in an older node format version in the
+// current format and returns the number of rewritten nodes. Older nodes are
+// upgraded in memory when they are read, so the tree stays usable until it
+// is migrated.
+func Migrate(pager *pg.Pager) (int, error) {
+	var migrated int
+	txErr := pager.Atomic(func() error {
+		root, rootErr := pager.ReadRootNode()
+		if rootErr != nil {
+			return rootErr
+		}
+
+		var migrateErr error
+		migrated, migrateErr = migrateSubtree(pager, root)
+		return migrateErr
+	})
+
+	return migrated, txErr
+}
+
+func migrateSubtree(pager *pg.Pager, pagedNode *pg.PagedNode) (int, error) {
+	pageData, readErr := pager.ReadPage(pagedNode.Page)
+	if readErr != nil {
+		return 0, readErr
+	}
+
+	header, headerErr := node.NewNodeHeaderFromBuffer(pageData[:node.NODE_HEADER_SIZE])
+	if headerErr != nil {
+		return 0, headerErr
+	}
+
+	migrated := 0
+	if header.FormatVersion != node.NODE_FORMAT_VERSION {
+		if err := pager.WritePagedNode(pagedNode); err != nil {
+			return 0, fmt.Errorf("failed to migrate node in page %d: %w", pagedNode.Page, err)
+		}
+		migrated += 1
+	}
+
+	internalNode, isInternal := pagedNode.Node.(*node.InternalNode)
+	if !isInternal {
+		return migrated, nil
+	}
+
+	for index := uint32(0); index < internalNode.GetElementsCount(); index++ {
+		keyRef, keyRefErr := internalNode.GetKeyPageRefByIndex(index)
+		if keyRefErr != nil {
+			return 0, keyRefErr
+		}
+
+		child, childErr := pager.ReadPagedNode(keyRef.PageId)
+		if childErr != nil {
+			return 0, childErr
+		}
+
+		childMigrated, migrateErr := migrateSubtree(pager, child)
+		if migrateErr != nil {
+			return 0, migrateErr
+		}
+		migrated += childMigrated
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,102 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/pager"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLegacySubtree rewrites the nodes of the subtree with the 100 byte
+// slots of the legacy format and returns the number of rewritten nodes
+func writeLegacySubtree(t *testing.T, p *pager.Pager, pageId uint32) int {
+	pagedNode, readErr := p.ReadPagedNode(pageId)
+	assert.NoError(t, readErr)
+
+	// legacy nodes use the full node size to fit the wide slots
+	header := *pagedNode.Node.GetHeader()
+	header.NodeSize = p.NodeSize()
+	header.FormatVersion = node.LegacyNodeFormatVersion
+	buf := make([]byte, header.NodeSize)
+	end := header.NodeSize
+
+	written := 1
+	for index := uint32(0); index < header.ElementsCount; index++ {
+		keyRef, keyRefErr := pagedNode.Node.GetKeyRefeferenceByIndex(index)
+		assert.NoError(t, keyRefErr)
+
+		var entry []byte
+		var value uint32
+		switch ref := keyRef.(type) {
+		case *node.KeyDataReference:
+			data := pagedNode.Node.(*node.LeafNode).GetKeyRefData(ref)
+			entry = append(ref.Key, data...)
+			value = ref.Length
+		case *node.KeyPageReference:
+			entry = ref.Key
+			value = ref.PageId
+			written += writeLegacySubtree(t, p, ref.PageId)
+		}
+
+		end -= uint32(len(entry))
+		copy(buf[end:], entry)
+		slot := buf[index*node.LEGACY_KEY_REF_SIZE:]
+		binary.LittleEndian.PutUint32(slot[0:4], end)
+		binary.LittleEndian.PutUint32(slot[4:8], uint32(len(keyRef.GetKey())))
+		binary.LittleEndian.PutUint32(slot[8:12], value)
+	}
+	header.FreeSpaceStartOffset = header.ElementsCount * node.LEGACY_KEY_REF_SIZE
+	header.FreeSpaceEndOffset = end
+
+	headerData, encodeErr := header.Encode()
+	assert.NoError(t, encodeErr)
+	page := p.NewPageBuffer()
+	copy(page, headerData)
+	copy(page[node.NODE_HEADER_SIZE:], buf)
+	assert.NoError(t, p.WritePage(pageId, page))
+
+	return written
+}
+
+func TestMigrateRewritesLegacyNodes(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "migrate.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	assert.NoError(t, initRootNode(pager, 200))
+
+	for key := uint32(0); key < 100; key++ {
+		assert.NoError(t, Insert(pager, uint32Key(key), []byte(fmt.Sprintf("data%d", key))))
+	}
+
+	root, rootErr := pager.ReadRootNode()
+	assert.NoError(t, rootErr)
+	legacyNodes := writeLegacySubtree(t, pager, root.Page)
+	assert.Greater(t, legacyNodes, 1)
+
+	// legacy nodes are readable before the migration
+	data, getErr := Get(pager, uint32Key(42))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("data42"), data)
+
+	migrated, migrateErr := Migrate(pager)
+	assert.NoError(t, migrateErr)
+	assert.Equal(t, legacyNodes, migrated)
+
+	migrated, migrateErr = Migrate(pager)
+	assert.NoError(t, migrateErr)
+	assert.Zero(t, migrated)
+
+	assert.Equal(t, keysInRange(0, 100), checkTree(t, pager))
+	for key := uint32(0); key < 100; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+	}
+}
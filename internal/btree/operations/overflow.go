@@ -0,0 +1,145 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"io"
+)
+
+// insertValue inserts the key into the leaf, values too large for the leaf
+// are written to overflow pages first
+func insertValue(pager *pg.Pager, leaf *node.LeafNode, key []byte, data []byte) (*node.LeafNodeInsertResult, error) {
+	pointer, writeErr := writeOverflowValue(pager, leaf, key, data)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return insertEntry(pager, leaf, key, data, pointer)
+}
+
+// insertEntry inserts the key with the data or with the pointer to the
+// overflow pages when it is not nil
+func insertEntry(pager *pg.Pager, leaf *node.LeafNode, key []byte, data []byte, pointer *node.OverflowPointer) (*node.LeafNodeInsertResult, error) {
+	if pointer == nil {
+		return leaf.Insert(key, data, pager.Comparator())
+	}
+
+	return leaf.InsertOverflow(key, pointer, pager.Comparator())
+}
+
+// updateEntry replaces the value of the key at the index with the data or
+// with the pointer to the overflow pages when it is not nil, the overflow
+// pages of the old value are freed
+func updateEntry(pager *pg.Pager, leaf *node.LeafNode, index uint32, data []byte, pointer *node.OverflowPointer) (*node.LeafNodeInsertResult, error) {
+	keyRef, keyRefErr := leaf.GetKeyDataRefByIndex(index)
+	if keyRefErr != nil {
+		return nil, keyRefErr
+	}
+
+	// the entry of the old value is overwritten by the update
+	oldPointer, pointerErr := overflowPointer(leaf, keyRef)
+	if pointerErr != nil {
+		return nil, pointerErr
+	}
+
+	var updateResult *node.LeafNodeInsertResult
+	var updateErr error
+	if pointer == nil {
+		updateResult, updateErr = leaf.UpdateAtIndex(index, data, pager.Comparator())
+	} else {
+		updateResult, updateErr = leaf.UpdateOverflowAtIndex(index, pointer, pager.Comparator())
+	}
+
+	if updateErr != nil {
+		return nil, updateErr
+	}
+
+	if oldPointer != nil {
+		if err := pager.FreeOverflow(oldPointer.PageId, oldPointer.Length); err != nil {
+			return nil, err
+		}
+	}
+
+	return updateResult, nil
+}
+
+// readValue returns a copy of the value of the entry, the value of an
+// overflow entry is reassembled from its pages
+func readValue(pager *pg.Pager, leaf *node.LeafNode, keyRef *node.KeyDataReference) ([]byte, error) {
+	pointer, pointerErr := overflowPointer(leaf, keyRef)
+	if pointerErr != nil {
+		return nil, pointerErr
+	}
+
+	if pointer != nil {
+		return pager.ReadOverflow(pointer.PageId, pointer.Length)
+	}
+
+	// copy the data so the caller does not hold a reference into the page buffer
+	data := leaf.GetKeyRefData(keyRef)
+	result := make([]byte, len(data))
+	copy(result, data)
+
+	return result, nil
+}
+
+// valueReader returns a reader over the value of the entry, the pages of an
+// overflow entry are read on demand
+func valueReader(pager *pg.Pager, leaf *node.LeafNode, keyRef *node.KeyDataReference) (io.ReadSeeker, error) {
+	pointer, pointerErr := overflowPointer(leaf, keyRef)
+	if pointerErr != nil {
+		return nil, pointerErr
+	}
+
+	if pointer != nil {
+		return pager.NewOverflowReader(pointer.PageId, pointer.Length), nil
+	}
+
+	data, readErr := readValue(pager, leaf, keyRef)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// writeOverflowValue writes values too large for the leaf to overflow pages
+// and returns nil for values stored in the leaf
+func writeOverflowValue(pager *pg.Pager, leaf *node.LeafNode, key []byte, data []byte) (*node.OverflowPointer, error) {
+	if !leaf.NeedsOverflow(key, data) {
+		return nil, nil
+	}
+
+	pageId, writeErr := pager.WriteOverflow(data)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return &node.OverflowPointer{PageId: pageId, Length: uint32(len(data))}, nil
+}
+
+// freeOverflowValue frees the overflow pages of the entry, it must be called
+// before the entry is removed from the leaf
+func freeOverflowValue(pager *pg.Pager, leaf *node.LeafNode, keyRef *node.KeyDataReference) error {
+	pointer, pointerErr := overflowPointer(leaf, keyRef)
+	if pointerErr != nil {
+		return pointerErr
+	}
+
+	if pointer == nil {
+		return nil
+	}
+
+	return pager.FreeOverflow(pointer.PageId, pointer.Length)
+}
+
+// overflowPointer returns the pointer stored in an overflow entry and nil for
+// entries holding their value inline
+func overflowPointer(leaf *node.LeafNode, keyRef *node.KeyDataReference) (*node.OverflowPointer, error) {
+	if !keyRef.Overflow {
+		return nil, nil
+	}
+
+	return node.DecodeOverflowPointer(leaf.GetKeyRefData(keyRef))
+}
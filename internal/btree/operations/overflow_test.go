@@ -0,0 +1,182 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func largeValue(key uint32, length int) []byte {
+	return bytes.Repeat([]byte(fmt.Sprintf("%04d", key)), length/4)
+}
+
+func TestOverflowValuesAcrossSplits(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, nil)
+	for key := uint32(0); key < 200; key++ {
+		data := []byte(fmt.Sprintf("data%d", key))
+		if key%3 == 0 {
+			data = largeValue(key, 20000)
+		}
+		assert.NoError(t, Insert(pager, uint32Key(key), data))
+	}
+	assert.Equal(t, keysInRange(0, 200), checkTree(t, pager))
+
+	for key := uint32(0); key < 200; key += 3 {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, largeValue(key, 20000), data)
+	}
+
+	cursor := NewCursor(pager, nil)
+	count := 0
+	for valid := cursor.First(); valid; valid = cursor.Next() {
+		key := uint32FromKey(cursor.Key())
+		if key%3 == 0 {
+			assert.Equal(t, largeValue(key, 20000), cursor.Value())
+		} else {
+			assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), cursor.Value())
+		}
+		count++
+	}
+	assert.NoError(t, cursor.Err())
+	assert.Equal(t, 200, count)
+}
+
+func TestMixedValueSizesAcrossSplits(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	// values just under the overflow cut-off stay inline next to small ones
+	random := rand.New(rand.NewSource(42))
+	values := map[string][]byte{}
+	pager := newCursorTestPager(t, dbFileName, nil)
+	for round := 0; round < 2; round++ {
+		for index := 0; index < 500; index++ {
+			key := fmt.Sprintf("k%05d", random.Intn(500))
+			data := bytes.Repeat([]byte{byte(index)}, random.Intn(9000))
+			assert.NoError(t, Upsert(pager, []byte(key), data), "key %s with %d bytes", key, len(data))
+			values[key] = data
+		}
+	}
+
+	for key, expected := range values {
+		data, getErr := Get(pager, []byte(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, expected, data)
+	}
+}
+
+func TestOverflowUpdateFreesPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 10))
+	pageCount := pager.PageCount()
+	usedPages := pageCount - pager.FreePageCount()
+
+	assert.NoError(t, Update(pager, uint32Key(5), largeValue(5, 20000)))
+	assert.Greater(t, pager.PageCount(), pageCount)
+	overflowPages := pager.PageCount() - pageCount
+
+	assert.NoError(t, Update(pager, uint32Key(5), largeValue(5, 30000)))
+	data, getErr := Get(pager, uint32Key(5))
+	assert.NoError(t, getErr)
+	assert.Equal(t, largeValue(5, 30000), data)
+
+	assert.NoError(t, Update(pager, uint32Key(5), []byte("small")))
+	data, getErr = Get(pager, uint32Key(5))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("small"), data)
+	assert.Equal(t, usedPages, pager.PageCount()-pager.FreePageCount())
+	assert.GreaterOrEqual(t, pager.FreePageCount(), overflowPages)
+}
+
+func TestOverflowDeleteFreesPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, nil)
+	pageCount := pager.PageCount()
+
+	for key := uint32(0); key < 20; key++ {
+		assert.NoError(t, Insert(pager, uint32Key(key), largeValue(key, 10000)))
+	}
+	for key := uint32(0); key < 20; key += 2 {
+		assert.NoError(t, Delete(pager, uint32Key(key)))
+	}
+	for key := uint32(1); key < 20; key += 2 {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, largeValue(key, 10000), data)
+	}
+
+	for key := uint32(1); key < 20; key += 2 {
+		assert.NoError(t, Delete(pager, uint32Key(key)))
+	}
+	assert.Empty(t, checkTree(t, pager))
+	assert.Equal(t, pager.PageCount()-pageCount, pager.FreePageCount())
+}
+
+func TestOpenValue(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 10))
+	assert.NoError(t, Update(pager, uint32Key(5), largeValue(5, 20000)))
+
+	for _, key := range []uint32{3, 5} {
+		expected, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+
+		reader, openErr := OpenValue(pager, uint32Key(key))
+		assert.NoError(t, openErr)
+		data, readErr := io.ReadAll(reader)
+		assert.NoError(t, readErr)
+		assert.Equal(t, expected, data)
+	}
+
+	_, openErr := OpenValue(pager, uint32Key(10))
+	assert.ErrorIs(t, openErr, ErrKeyNotFound)
+}
+
+func TestUpsertOverflowReplacesValue(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 10))
+	assert.NoError(t, Update(pager, uint32Key(5), largeValue(5, 20000)))
+	pageCount := pager.PageCount()
+
+	// small values written to overflow pages stay there
+	for _, key := range []uint32{5, 20} {
+		pageId, writeErr := pager.WriteOverflow([]byte("blob"))
+		assert.NoError(t, writeErr)
+		pointer := &node.OverflowPointer{PageId: pageId, Length: 4}
+		assert.NoError(t, UpsertOverflow(pager, uint32Key(key), pointer))
+
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, []byte("blob"), data)
+	}
+
+	// the first blob is written before the pages of the replaced value are
+	// freed, the second one reuses one of them. Each write also replaces the
+	// page of the leaf with a copy.
+	assert.Equal(t, pageCount+1, pager.PageCount())
+	assert.Equal(t, uint32(5), pager.FreePageCount())
+}
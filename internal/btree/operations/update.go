@@ -0,0 +1,80 @@
+package operations
+
+import (
+	"bricker-db/internal/btree/node"
+	pg "bricker-db/internal/pager"
+	"fmt"
+)
+
+// Update replaces the data of an existing key
+func Update(pager *pg.Pager, key []byte, data []byte) error {
+	return write(pager, key, data, nil, false)
+}
+
+// Upsert inserts the key or replaces its data when it already exists
+func Upsert(pager *pg.Pager, key []byte, data []byte) error {
+	return write(pager, key, data, nil, true)
+}
+
+// UpsertOverflow stores a value already written to overflow pages under the
+// key, the pages belong to the tree afterwards
+func UpsertOverflow(pager *pg.Pager, key []byte, pointer *node.OverflowPointer) error {
+	return write(pager, key, nil, pointer, true)
+}
+
+func write(pager *pg.Pager, key []byte, data []byte, pointer *node.OverflowPointer, insertMissing bool) error {
+	if len(key) > node.MAX_KEY_SIZE {
+		return node.ErrKeyTooLarge
+	}
+
+	// the latches are released once the write is committed
+	latches := newPathLatches(pager)
+	defer latches.release()
+
+	return pager.Atomic(func() error {
+		return writeKey(pager, latches, key, data, pointer, insertMissing)
+	})
+}
+
+func writeKey(pager *pg.Pager, latches *pathLatches, key []byte, data []byte, pointer *node.OverflowPointer, insertMissing bool) error {
+	breadcrumbs, searchErr := findPositionForWrite(pager, latches, key, data, pointer)
+	if searchErr != nil {
+		return searchErr
+	}
+
+	leafBreadcrumb := breadcrumbs[len(breadcrumbs)-1]
+	leaf, leafOk := leafBreadcrumb.pagedNode.Node.(*node.LeafNode)
+	if !leafOk {
+		return fmt.Errorf("unable to cast to leaf node")
+	}
+
+	exists, index, findErr := node.FindPositionForKey(leaf, key, pager.Comparator())
+	if findErr != nil {
+		return fmt.Errorf("failed to find position of key %q: %w", key, findErr)
+	}
+
+	if !exists && !insertMissing {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	if pointer == nil {
+		var overflowErr error
+		if pointer, overflowErr = writeOverflowValue(pager, leaf, key, data); overflowErr != nil {
+			return overflowErr
+		}
+	}
+
+	var writeResult *node.LeafNodeInsertResult
+	var writeErr error
+	if exists {
+		writeResult, writeErr = updateEntry(pager, leaf, index, data, pointer)
+	} else {
+		writeResult, writeErr = insertEntry(pager, leaf, key, data, pointer)
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	return propagateInsertUpdates(pager, writeResult.Metadata, breadcrumbs)
+}
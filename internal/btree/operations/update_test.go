@@ -0,0 +1,131 @@
+package operations
+
+import (
+	"bricker-db/internal/pager"
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateOperation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "update.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 3))
+
+	assert.ErrorIs(t, Update(pager, uint32Key(3), []byte("data")), ErrKeyNotFound)
+	_, getErr := Get(pager, uint32Key(3))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+
+	assert.NoError(t, Update(pager, uint32Key(1), []byte("new")))
+	data, get1Err := Get(pager, uint32Key(1))
+	assert.NoError(t, get1Err)
+	assert.Equal(t, []byte("new"), data)
+
+	assert.NoError(t, Update(pager, uint32Key(1), []byte("newLongerData")))
+	data, get1Err = Get(pager, uint32Key(1))
+	assert.NoError(t, get1Err)
+	assert.Equal(t, []byte("newLongerData"), data)
+}
+
+func TestUpsertOperation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "update.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 3))
+
+	assert.NoError(t, Upsert(pager, uint32Key(3), []byte("inserted")))
+	assert.NoError(t, Upsert(pager, uint32Key(0), []byte("updated")))
+
+	data, getErr := Get(pager, uint32Key(3))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("inserted"), data)
+
+	data, getErr = Get(pager, uint32Key(0))
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("updated"), data)
+
+	assert.Equal(t, keysInRange(0, 4), checkTree(t, pager))
+}
+
+func TestUpdateOperationSplitsFullLeaves(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "update.db"
+	defer os.Remove(dbFileName)
+
+	pager := newCursorTestPager(t, dbFileName, keysInRange(0, 500))
+
+	largeData := func(key uint32) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("%d", key)), 20)
+	}
+
+	for key := uint32(0); key < 500; key += 2 {
+		assert.NoError(t, Update(pager, uint32Key(key), largeData(key)))
+	}
+
+	assert.Equal(t, keysInRange(0, 500), checkTree(t, pager))
+	for key := uint32(0); key < 500; key++ {
+		data, getErr := Get(pager, uint32Key(key))
+		assert.NoError(t, getErr)
+		if key%2 == 0 {
+			assert.Equal(t, largeData(key), data)
+		} else {
+			assert.Equal(t, []byte(fmt.Sprintf("data%d", key)), data)
+		}
+	}
+}
+
+func TestUpsertOperationConcurrentWriters(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "update.db"
+	defer os.Remove(dbFileName)
+
+	p, pagerErr := pager.NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	defer p.CloseFile()
+	assert.NoError(t, Init(p))
+
+	// the writers interleave their keys so they share leaves, every tenth
+	// value is stored in overflow pages
+	const writers = 4
+	const rounds = 3
+	const keys = 600
+	value := func(key uint32, round int) []byte {
+		size := 20 + round*30
+		if key%10 == 0 {
+			size = int(p.NodeSize())
+		}
+		return bytes.Repeat([]byte{byte(key + uint32(round))}, size)
+	}
+
+	var wg sync.WaitGroup
+	for w := uint32(0); w < writers; w++ {
+		wg.Add(1)
+		go func(writer *pager.Pager, first uint32) {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				for key := first; key < keys; key += writers {
+					assert.NoError(t, Upsert(writer, uint32Key(key), value(key, round)))
+				}
+			}
+		}(p.NewWriter(), w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, keysInRange(0, keys), checkTree(t, p))
+	for key := uint32(0); key < keys; key++ {
+		data, getErr := Get(p, uint32Key(key))
+		assert.NoError(t, getErr)
+		assert.Equal(t, value(key, rounds-1), data)
+	}
+
+	// the overflow pages of the replaced values were freed and reused, every
+	// round of overflow values would need keys/10 pages otherwise
+	assert.Less(t, p.PageCount(), uint32(rounds*keys/10))
+}
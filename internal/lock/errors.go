@@ -0,0 +1,6 @@
+package lock
+
+import "errors"
+
+var ErrDeadlock = errors.New("lock request closes a deadlock")
+var ErrLockTimeout = errors.New("lock was not granted before the timeout")
@@ -0,0 +1,271 @@
+package lock
+
+import (
+	"bricker-db/internal/btree/node"
+	"bytes"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Mode uint8
+
+const (
+	Shared Mode = iota
+	Exclusive
+)
+
+type grant struct {
+	owner    uint64
+	keyRange Range
+	mode     Mode
+	// locks of the key the grant is listed in, nil for the other ranges
+	key *keyLocks
+}
+
+// keyLocks are the locks granted on a single key
+type keyLocks struct {
+	key    []byte
+	grants []*grant
+}
+
+func (g *grant) conflicts(owner uint64, mode Mode) bool {
+	return g.owner != owner && (g.mode == Exclusive || mode == Exclusive)
+}
+
+// waiter is an owner blocked on a lock request, the owners it waits for are
+// the edges of the wait-for graph
+type waiter struct {
+	blockers map[uint64]bool
+	// signaled when locks are released, the request is checked again
+	wake chan struct{}
+}
+
+// Manager grants shared and exclusive locks on keys and key ranges to
+// owners, the locks of an owner are held until ReleaseAll. A request waits
+// while an overlapping lock of another owner conflicts with it, the request
+// closing a cycle of owners waiting for each other fails with ErrDeadlock.
+type Manager struct {
+	comparator node.Comparator
+	mu         sync.Mutex
+	lastOwner  uint64
+	// locks of single keys ordered by the comparator, the other ranges are
+	// scanned
+	keys    []*keyLocks
+	ranges  []*grant
+	owners  map[uint64][]*grant
+	waiting map[uint64]*waiter
+}
+
+func NewManager(comparator node.Comparator) *Manager {
+	return &Manager{
+		comparator: comparator,
+		owners:     make(map[uint64][]*grant),
+		waiting:    make(map[uint64]*waiter),
+	}
+}
+
+// NewOwner returns an owner id no lock was requested with yet
+func (m *Manager) NewOwner() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastOwner += 1
+	return m.lastOwner
+}
+
+// Lock grants the lock to the owner once no other owner holds a conflicting
+// one. ErrDeadlock is returned when the owners holding them wait for the
+// owner, ErrLockTimeout when the timeout passes first, a negative timeout
+// waits without limit.
+func (m *Manager) Lock(owner uint64, keyRange Range, mode Mode, timeout time.Duration) error {
+	var timer <-chan time.Time
+	if timeout >= 0 {
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		timer = deadline.C
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.holds(owner, keyRange, mode) {
+		return nil
+	}
+
+	// the bounds are kept until the lock is released, the caller may reuse
+	// its buffers
+	keyRange = Range{Start: bytes.Clone(keyRange.Start), End: bytes.Clone(keyRange.End), IncludesEnd: keyRange.IncludesEnd}
+
+	for {
+		blockers := m.blockers(owner, keyRange, mode)
+		if len(blockers) == 0 {
+			delete(m.waiting, owner)
+			m.grant(&grant{owner: owner, keyRange: keyRange, mode: mode})
+			return nil
+		}
+
+		current, isWaiting := m.waiting[owner]
+		if !isWaiting {
+			current = &waiter{wake: make(chan struct{}, 1)}
+			m.waiting[owner] = current
+		}
+		current.blockers = blockers
+
+		if m.waitsFor(blockers, owner) {
+			delete(m.waiting, owner)
+			return ErrDeadlock
+		}
+
+		m.mu.Unlock()
+		select {
+		case <-current.wake:
+			m.mu.Lock()
+		case <-timer:
+			m.mu.Lock()
+			delete(m.waiting, owner)
+			return ErrLockTimeout
+		}
+	}
+}
+
+// ReleaseAll releases the locks of the owner and wakes the waiting requests
+func (m *Manager) ReleaseAll(owner uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	grants, holds := m.owners[owner]
+	if !holds {
+		return
+	}
+	delete(m.owners, owner)
+
+	for _, released := range grants {
+		if released.key == nil {
+			m.ranges = removeGrant(m.ranges, released)
+			continue
+		}
+
+		released.key.grants = removeGrant(released.key.grants, released)
+		if len(released.key.grants) == 0 {
+			index, _ := m.findKey(released.key.key)
+			m.keys = slices.Delete(m.keys, index, index+1)
+		}
+	}
+
+	// the waiters no longer wait for the owner, the edges of the other
+	// owners hold until they release their locks
+	for _, blocked := range m.waiting {
+		delete(blocked.blockers, owner)
+		select {
+		case blocked.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// holds reports whether the owner already holds the lock or a stronger one
+// on the same range
+func (m *Manager) holds(owner uint64, keyRange Range, mode Mode) bool {
+	for _, held := range m.owners[owner] {
+		if held.mode >= mode && held.keyRange.equals(keyRange, m.comparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockers returns the owners holding locks which conflict with the request
+func (m *Manager) blockers(owner uint64, keyRange Range, mode Mode) map[uint64]bool {
+	blockers := make(map[uint64]bool)
+	check := func(held *grant) {
+		if held.conflicts(owner, mode) && held.keyRange.overlaps(keyRange, m.comparator) {
+			blockers[held.owner] = true
+		}
+	}
+
+	// the keys locked inside of the range follow its start
+	index := 0
+	if keyRange.Start != nil {
+		index, _ = m.findKey(keyRange.Start)
+	}
+	for ; index < len(m.keys) && keyRange.startsBeforeEnd(m.keys[index].key, m.comparator); index++ {
+		for _, held := range m.keys[index].grants {
+			check(held)
+		}
+	}
+
+	for _, held := range m.ranges {
+		check(held)
+	}
+
+	return blockers
+}
+
+// waitsFor reports whether one of the owners waits for the target, directly
+// or through the owners it waits for
+func (m *Manager) waitsFor(owners map[uint64]bool, target uint64) bool {
+	visited := make(map[uint64]bool)
+	pending := make([]uint64, 0, len(owners))
+	for owner := range owners {
+		pending = append(pending, owner)
+	}
+
+	for len(pending) > 0 {
+		owner := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if owner == target {
+			return true
+		}
+
+		if visited[owner] {
+			continue
+		}
+		visited[owner] = true
+
+		if blocked, isWaiting := m.waiting[owner]; isWaiting {
+			for blocker := range blocked.blockers {
+				pending = append(pending, blocker)
+			}
+		}
+	}
+
+	return false
+}
+
+func (m *Manager) grant(granted *grant) {
+	m.owners[granted.owner] = append(m.owners[granted.owner], granted)
+	if !granted.keyRange.isKey(m.comparator) {
+		m.ranges = append(m.ranges, granted)
+		return
+	}
+
+	index, found := m.findKey(granted.keyRange.Start)
+	if !found {
+		m.keys = slices.Insert(m.keys, index, &keyLocks{key: granted.keyRange.Start})
+	}
+	granted.key = m.keys[index]
+	granted.key.grants = append(granted.key.grants, granted)
+}
+
+// findKey returns the index of the locks of the key, or the index they are
+// inserted at when the key is not locked
+func (m *Manager) findKey(key []byte) (int, bool) {
+	index := sort.Search(len(m.keys), func(index int) bool {
+		return m.comparator.Compare(m.keys[index].key, key) >= 0
+	})
+
+	return index, index < len(m.keys) && m.comparator.Compare(m.keys[index].key, key) == 0
+}
+
+func removeGrant(grants []*grant, removed *grant) []*grant {
+	for index, held := range grants {
+		if held == removed {
+			return append(grants[:index], grants[(index+1):]...)
+		}
+	}
+
+	return grants
+}
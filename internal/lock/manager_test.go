@@ -0,0 +1,183 @@
+package lock
+
+import (
+	"bricker-db/internal/btree/node"
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestManager() *Manager {
+	return NewManager(node.BytewiseComparator)
+}
+
+func TestManagerSharedAndExclusiveLocks(t *testing.T) {
+	manager := newTestManager()
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	assert.NoError(t, manager.Lock(first, Key([]byte("a")), Shared, -1))
+	assert.NoError(t, manager.Lock(second, Key([]byte("a")), Shared, -1))
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("a")), Exclusive, 10*time.Millisecond), ErrLockTimeout)
+	// a zero timeout does not wait
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("a")), Exclusive, 0), ErrLockTimeout)
+	assert.NoError(t, manager.Lock(second, Key([]byte("b")), Exclusive, 0))
+
+	// the exclusive lock is granted once the shared lock of the other owner
+	// is released
+	granted := make(chan error)
+	go func() {
+		granted <- manager.Lock(second, Key([]byte("a")), Exclusive, time.Second)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	manager.ReleaseAll(first)
+	assert.NoError(t, <-granted)
+
+	assert.ErrorIs(t, manager.Lock(first, Key([]byte("a")), Shared, 10*time.Millisecond), ErrLockTimeout)
+	manager.ReleaseAll(second)
+	assert.NoError(t, manager.Lock(first, Key([]byte("a")), Exclusive, -1))
+}
+
+func TestManagerRangeLocks(t *testing.T) {
+	manager := newTestManager()
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	// [b, d) conflicts with the keys and ranges it overlaps
+	assert.NoError(t, manager.Lock(first, Range{Start: []byte("b"), End: []byte("d")}, Shared, -1))
+	timeout := 10 * time.Millisecond
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("b")), Exclusive, timeout), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("c")), Exclusive, timeout), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Range{End: []byte("b"), IncludesEnd: true}, Exclusive, timeout), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Range{Start: []byte("c")}, Exclusive, timeout), ErrLockTimeout)
+
+	assert.NoError(t, manager.Lock(second, Key([]byte("d")), Exclusive, -1))
+	assert.NoError(t, manager.Lock(second, Range{End: []byte("b")}, Exclusive, -1))
+	assert.NoError(t, manager.Lock(second, Range{Start: []byte("c"), End: []byte("e")}, Shared, -1))
+
+	// a range lock waits for the key locks inside of it
+	assert.ErrorIs(t, manager.Lock(first, Range{Start: []byte("c")}, Shared, timeout), ErrLockTimeout)
+	manager.ReleaseAll(second)
+	assert.NoError(t, manager.Lock(first, Range{Start: []byte("c")}, Exclusive, -1))
+}
+
+func TestManagerDetectsDeadlock(t *testing.T) {
+	manager := newTestManager()
+	first, second, third := manager.NewOwner(), manager.NewOwner(), manager.NewOwner()
+
+	assert.NoError(t, manager.Lock(first, Key([]byte("a")), Exclusive, -1))
+	assert.NoError(t, manager.Lock(second, Key([]byte("b")), Exclusive, -1))
+	assert.NoError(t, manager.Lock(third, Key([]byte("c")), Exclusive, -1))
+
+	// the owners wait for each other in a cycle, the request closing it is
+	// the victim
+	var wg sync.WaitGroup
+	results := make([]error, 2)
+	for index, request := range []struct {
+		owner uint64
+		key   string
+	}{{first, "b"}, {second, "c"}} {
+		wg.Add(1)
+		go func(index int, owner uint64, key string) {
+			defer wg.Done()
+			results[index] = manager.Lock(owner, Key([]byte(key)), Exclusive, -1)
+		}(index, request.owner, request.key)
+	}
+	assert.Eventually(t, func() bool {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.waiting) == 2
+	}, time.Second, time.Millisecond)
+
+	assert.ErrorIs(t, manager.Lock(third, Key([]byte("a")), Exclusive, -1), ErrDeadlock)
+	manager.ReleaseAll(third)
+	manager.ReleaseAll(second)
+	wg.Wait()
+	assert.NoError(t, results[0])
+	assert.NoError(t, results[1])
+}
+
+func TestManagerUpgradeDeadlock(t *testing.T) {
+	manager := newTestManager()
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	assert.NoError(t, manager.Lock(first, Key([]byte("a")), Shared, -1))
+	assert.NoError(t, manager.Lock(second, Key([]byte("a")), Shared, -1))
+	// a lock the owner holds is granted again without waiting
+	assert.NoError(t, manager.Lock(second, Key([]byte("a")), Shared, -1))
+
+	upgraded := make(chan error)
+	go func() {
+		upgraded <- manager.Lock(first, Key([]byte("a")), Exclusive, -1)
+	}()
+	assert.Eventually(t, func() bool {
+		manager.mu.Lock()
+		defer manager.mu.Unlock()
+		return len(manager.waiting) == 1
+	}, time.Second, time.Millisecond)
+
+	// both owners upgrading their shared locks wait for each other
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("a")), Exclusive, -1), ErrDeadlock)
+	manager.ReleaseAll(second)
+	assert.NoError(t, <-upgraded)
+}
+
+func TestManagerKeepsCopiesOfTheBounds(t *testing.T) {
+	manager := newTestManager()
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	// the buffer is reused by the caller after the lock is granted
+	buf := []byte("a")
+	assert.NoError(t, manager.Lock(first, Key(buf), Exclusive, -1))
+	buf[0] = 'b'
+	assert.NoError(t, manager.Lock(first, Key(buf), Exclusive, -1))
+
+	timeout := 10 * time.Millisecond
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("a")), Shared, timeout), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("b")), Shared, timeout), ErrLockTimeout)
+
+	manager.ReleaseAll(first)
+	assert.Empty(t, manager.keys)
+	assert.NoError(t, manager.Lock(second, Key([]byte("a")), Exclusive, -1))
+}
+
+type caseInsensitiveComparator struct{}
+
+func (caseInsensitiveComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(bytes.ToLower(a), bytes.ToLower(b))
+}
+
+func (caseInsensitiveComparator) Name() string {
+	return "case-insensitive"
+}
+
+func TestManagerMatchesKeysWithTheComparator(t *testing.T) {
+	manager := NewManager(caseInsensitiveComparator{})
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	// the keys the comparator orders as equal are the same key
+	assert.NoError(t, manager.Lock(first, Key([]byte("KEY")), Exclusive, -1))
+	assert.NoError(t, manager.Lock(first, Key([]byte("key")), Exclusive, -1))
+	assert.Len(t, manager.keys, 1)
+
+	timeout := 10 * time.Millisecond
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte("Key")), Shared, timeout), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Range{Start: []byte("k"), End: []byte("L")}, Shared, timeout), ErrLockTimeout)
+	assert.NoError(t, manager.Lock(second, Range{Start: []byte("KEZ")}, Shared, -1))
+
+	manager.ReleaseAll(first)
+	assert.Empty(t, manager.keys)
+	assert.NoError(t, manager.Lock(second, Key([]byte("key")), Exclusive, -1))
+}
+
+func TestManagerLocksTheEmptyKey(t *testing.T) {
+	manager := newTestManager()
+	first, second := manager.NewOwner(), manager.NewOwner()
+
+	// a nil key is the empty key, the other keys stay unlocked
+	assert.NoError(t, manager.Lock(first, Key(nil), Exclusive, -1))
+	assert.NoError(t, manager.Lock(second, Key([]byte("a")), Exclusive, 0))
+	assert.ErrorIs(t, manager.Lock(second, Key([]byte{}), Shared, 0), ErrLockTimeout)
+	assert.ErrorIs(t, manager.Lock(second, Range{End: []byte("a")}, Shared, 0), ErrLockTimeout)
+}
@@ -0,0 +1,57 @@
+package lock
+
+import "bricker-db/internal/btree/node"
+
+// Range is the set of keys a lock covers, a nil start is before the first
+// key and a nil end after the last one
+type Range struct {
+	Start []byte
+	End   []byte
+	// the end key is part of the range, the range of a single key
+	// includes it
+	IncludesEnd bool
+}
+
+// Key returns the range of the single key, a nil key is the empty key and
+// not an unbounded range
+func Key(key []byte) Range {
+	if key == nil {
+		key = []byte{}
+	}
+
+	return Range{Start: key, End: key, IncludesEnd: true}
+}
+
+func (r Range) isKey(comparator node.Comparator) bool {
+	return r.IncludesEnd && r.Start != nil && r.End != nil && comparator.Compare(r.Start, r.End) == 0
+}
+
+// startsBeforeEnd reports whether the key lies before the end of the range,
+// a nil key is before every end
+func (r Range) startsBeforeEnd(key []byte, comparator node.Comparator) bool {
+	if key == nil || r.End == nil {
+		return true
+	}
+
+	comparison := comparator.Compare(key, r.End)
+	return comparison < 0 || (comparison == 0 && r.IncludesEnd)
+}
+
+func (r Range) overlaps(other Range, comparator node.Comparator) bool {
+	return r.startsBeforeEnd(other.Start, comparator) && other.startsBeforeEnd(r.Start, comparator)
+}
+
+func (r Range) equals(other Range, comparator node.Comparator) bool {
+	return boundsEqual(r.Start, other.Start, comparator) && boundsEqual(r.End, other.End, comparator) &&
+		r.IncludesEnd == other.IncludesEnd
+}
+
+// boundsEqual reports whether the bounds are equal, nil bounds are only
+// equal to each other
+func boundsEqual(a []byte, b []byte, comparator node.Comparator) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return comparator.Compare(a, b) == 0
+}
@@ -0,0 +1,6 @@
+package mvcc
+
+import "errors"
+
+var ErrCorruptVersions = errors.New("versions of the value are corrupted")
+var ErrWriteConflict = errors.New("key was written by a transaction committed after this one began")
@@ -0,0 +1,111 @@
+package mvcc
+
+import (
+	"slices"
+	"sync"
+)
+
+// Oracle hands out the commit timestamps and tracks the timestamps the open
+// snapshots read at, the versions none of them reads can be pruned. A
+// snapshot reads at the visible timestamp, every commit up to it finished
+// installing its versions.
+type Oracle struct {
+	mu   sync.Mutex
+	last uint64
+	// timestamps handed out to commits which did not finish yet
+	pending map[uint64]bool
+	// signaled when a commit finishes
+	finished *sync.Cond
+	// number of open snapshots by timestamp
+	snapshots map[uint64]int
+}
+
+// NewOracle returns an oracle continuing after the last commit timestamp
+func NewOracle(last uint64) *Oracle {
+	o := &Oracle{last: last, pending: make(map[uint64]bool), snapshots: make(map[uint64]int)}
+	o.finished = sync.NewCond(&o.mu)
+	return o
+}
+
+// Next returns the timestamp of a new commit, the commit calls Finish once
+// its versions are installed or abandoned
+func (o *Oracle) Next() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.last += 1
+	o.pending[o.last] = true
+	return o.last
+}
+
+// Finish marks the commit as finished and waits until its timestamp is
+// visible, the snapshots taken afterwards see the commit
+func (o *Oracle) Finish(timestamp uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.pending, timestamp)
+	o.finished.Broadcast()
+	for o.visible() < timestamp {
+		o.finished.Wait()
+	}
+}
+
+// Last returns the timestamp handed out last
+func (o *Oracle) Last() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.last
+}
+
+// Snapshot opens a snapshot at the visible timestamp and returns it, the
+// snapshot is released with Release
+func (o *Oracle) Snapshot() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	timestamp := o.visible()
+	o.snapshots[timestamp] += 1
+	return timestamp
+}
+
+// Release closes a snapshot opened at the timestamp
+func (o *Oracle) Release(timestamp uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.snapshots[timestamp] -= 1
+	if o.snapshots[timestamp] == 0 {
+		delete(o.snapshots, timestamp)
+	}
+}
+
+// Snapshots returns the ascending timestamps of the open snapshots and of
+// the snapshot a transaction beginning now reads at, the later snapshots
+// read at or after it
+func (o *Oracle) Snapshots() []uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	snapshots := make([]uint64, 0, len(o.snapshots)+1)
+	for timestamp := range o.snapshots {
+		snapshots = append(snapshots, timestamp)
+	}
+	if visible := o.visible(); o.snapshots[visible] == 0 {
+		snapshots = append(snapshots, visible)
+	}
+	slices.Sort(snapshots)
+
+	return snapshots
+}
+
+// visible returns the timestamp before the oldest pending commit
+func (o *Oracle) visible() uint64 {
+	visible := o.last
+	for timestamp := range o.pending {
+		visible = min(visible, timestamp-1)
+	}
+
+	return visible
+}
@@ -0,0 +1,59 @@
+package mvcc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOracleSnapshots(t *testing.T) {
+	oracle := NewOracle(4)
+	oracle.Finish(oracle.Next())
+
+	first := oracle.Snapshot()
+	second := oracle.Snapshot()
+	assert.Equal(t, uint64(5), first)
+	oracle.Finish(oracle.Next())
+	third := oracle.Snapshot()
+	assert.Equal(t, uint64(6), third)
+	oracle.Finish(oracle.Next())
+
+	// a snapshot is open until all of its holders release it, the next
+	// snapshot reads at the last commit
+	assert.Equal(t, []uint64{5, 6, 7}, oracle.Snapshots())
+	oracle.Release(first)
+	assert.Equal(t, []uint64{5, 6, 7}, oracle.Snapshots())
+	oracle.Release(second)
+	assert.Equal(t, []uint64{6, 7}, oracle.Snapshots())
+	oracle.Release(third)
+	assert.Equal(t, []uint64{7}, oracle.Snapshots())
+	assert.Equal(t, uint64(7), oracle.Last())
+}
+
+func TestOracleSnapshotsSkipPendingCommits(t *testing.T) {
+	oracle := NewOracle(0)
+	slow := oracle.Next()
+	fast := oracle.Next()
+
+	// the snapshots do not wait for the pending commits, they read before
+	// the oldest one
+	assert.Equal(t, uint64(0), oracle.Snapshot())
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		oracle.Finish(fast)
+	}()
+	select {
+	case <-finished:
+		t.Fatal("commit finished before the older pending commit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, uint64(0), oracle.Snapshot())
+
+	oracle.Finish(slow)
+	<-finished
+	assert.Equal(t, uint64(2), oracle.Snapshot())
+	assert.Equal(t, []uint64{0, 2}, oracle.Snapshots())
+}
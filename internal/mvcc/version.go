@@ -0,0 +1,130 @@
+package mvcc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// timestamp u64, flags u8, data length u32
+const VERSION_HEADER_SIZE = 13
+
+const (
+	deletedFlag = 1 << iota
+	blobFlag
+)
+
+// Version is the value of a key written by the commit with the timestamp. A
+// deleted key is recorded as a version without data, the data of a blob is
+// the encoded overflow pointer of the value.
+type Version struct {
+	Timestamp uint64
+	Deleted   bool
+	Blob      bool
+	Data      []byte
+}
+
+// EncodeVersions encodes the versions of a key, they are ordered from the
+// newest one
+func EncodeVersions(versions []Version) []byte {
+	size := 0
+	for _, version := range versions {
+		size += VERSION_HEADER_SIZE + len(version.Data)
+	}
+
+	buf := make([]byte, 0, size)
+	for _, version := range versions {
+		var flags byte
+		if version.Deleted {
+			flags |= deletedFlag
+		}
+		if version.Blob {
+			flags |= blobFlag
+		}
+
+		buf = binary.LittleEndian.AppendUint64(buf, version.Timestamp)
+		buf = append(buf, flags)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(version.Data)))
+		buf = append(buf, version.Data...)
+	}
+
+	return buf
+}
+
+func DecodeVersions(data []byte) ([]Version, error) {
+	var versions []Version
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < VERSION_HEADER_SIZE {
+			return nil, fmt.Errorf("%w: truncated version header", ErrCorruptVersions)
+		}
+
+		timestamp := binary.LittleEndian.Uint64(data[offset:(offset + 8)])
+		flags := data[offset+8]
+		length := int(binary.LittleEndian.Uint32(data[(offset + 9):(offset + 13)]))
+		offset += VERSION_HEADER_SIZE
+
+		if len(data)-offset < length {
+			return nil, fmt.Errorf("%w: truncated version data", ErrCorruptVersions)
+		}
+
+		versions = append(versions, Version{
+			Timestamp: timestamp,
+			Deleted:   flags&deletedFlag != 0,
+			Blob:      flags&blobFlag != 0,
+			Data:      data[offset:(offset + length)],
+		})
+		offset += length
+	}
+
+	return versions, nil
+}
+
+// Visible returns the newest version committed at or before the timestamp
+func Visible(versions []Version, timestamp uint64) (Version, bool) {
+	for _, version := range versions {
+		if version.Timestamp <= timestamp {
+			return version, true
+		}
+	}
+
+	return Version{}, false
+}
+
+// Conflicts reports whether a version was committed after the timestamp a
+// transaction reads at, the transaction must not overwrite it
+func Conflicts(versions []Version, timestamp uint64) bool {
+	return len(versions) > 0 && versions[0].Timestamp > timestamp
+}
+
+// Prune splits the versions into the ones a reader can see and the ones no
+// reader can see anymore, snapshots are the ascending timestamps of the open
+// snapshots. A version is visible to the snapshots reading after its commit
+// and before the next one, the newest version to the readers beginning
+// later. The deletions older than the versions kept are dropped since a
+// missing version reads the same, the newest one only once every snapshot
+// sees it so that the writers still detect their conflict with it.
+func Prune(versions []Version, snapshots []uint64) ([]Version, []Version) {
+	var kept, dropped []Version
+	for index, version := range versions {
+		first, _ := slices.BinarySearch(snapshots, version.Timestamp)
+		visible := index == 0 ||
+			(first < len(snapshots) && snapshots[first] < versions[index-1].Timestamp)
+		if visible {
+			kept = append(kept, version)
+		} else {
+			dropped = append(dropped, version)
+		}
+	}
+
+	for len(kept) > 0 && kept[len(kept)-1].Deleted {
+		// the first version kept is the newest one
+		if len(kept) == 1 && len(snapshots) > 0 && snapshots[0] < kept[0].Timestamp {
+			break
+		}
+
+		dropped = append(dropped, kept[len(kept)-1])
+		kept = kept[:len(kept)-1]
+	}
+
+	return kept, dropped
+}
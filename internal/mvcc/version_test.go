@@ -0,0 +1,92 @@
+package mvcc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionsRoundTrip(t *testing.T) {
+	versions := []Version{
+		{Timestamp: 7, Data: []byte("new")},
+		{Timestamp: 5, Deleted: true, Data: []byte{}},
+		{Timestamp: 2, Blob: true, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+	}
+
+	decoded, decodeErr := DecodeVersions(EncodeVersions(versions))
+	assert.NoError(t, decodeErr)
+	assert.Equal(t, versions, decoded)
+
+	_, truncatedErr := DecodeVersions(EncodeVersions(versions)[:20])
+	assert.ErrorIs(t, truncatedErr, ErrCorruptVersions)
+}
+
+func TestVersionsVisibleAndConflicts(t *testing.T) {
+	versions := []Version{
+		{Timestamp: 9, Data: []byte("c")},
+		{Timestamp: 6, Deleted: true},
+		{Timestamp: 3, Data: []byte("a")},
+	}
+
+	_, visible := Visible(versions, 2)
+	assert.False(t, visible)
+
+	version, visible := Visible(versions, 8)
+	assert.True(t, visible)
+	assert.True(t, version.Deleted)
+
+	version, visible = Visible(versions, 9)
+	assert.True(t, visible)
+	assert.Equal(t, []byte("c"), version.Data)
+
+	assert.True(t, Conflicts(versions, 8))
+	assert.False(t, Conflicts(versions, 9))
+	assert.False(t, Conflicts(nil, 0))
+}
+
+func TestVersionsPrune(t *testing.T) {
+	versions := []Version{
+		{Timestamp: 9, Data: []byte("d")},
+		{Timestamp: 6, Data: []byte("c")},
+		{Timestamp: 4, Data: []byte("b")},
+		{Timestamp: 3, Data: []byte("a")},
+	}
+
+	// the snapshots keep the versions they read, the versions between them
+	// are dropped
+	kept, dropped := Prune(versions, []uint64{3, 7})
+	assert.Equal(t, []Version{versions[0], versions[1], versions[3]}, kept)
+	assert.Equal(t, []Version{versions[2]}, dropped)
+
+	kept, dropped = Prune(versions, nil)
+	assert.Equal(t, versions[:1], kept)
+	assert.Equal(t, versions[1:], dropped)
+
+	kept, dropped = Prune(versions, []uint64{2, 9})
+	assert.Equal(t, versions[:1], kept)
+	assert.Equal(t, versions[1:], dropped)
+}
+
+func TestVersionsPruneDeletions(t *testing.T) {
+	versions := []Version{
+		{Timestamp: 8, Deleted: true},
+		{Timestamp: 5, Data: []byte("b")},
+		{Timestamp: 3, Deleted: true},
+		{Timestamp: 2, Data: []byte("a")},
+	}
+
+	// the snapshot reading the older deletion sees no value without it
+	kept, dropped := Prune(versions, []uint64{3, 6})
+	assert.Equal(t, versions[:2], kept)
+	assert.ElementsMatch(t, versions[2:], dropped)
+
+	// the newest deletion is kept while a snapshot older than it is open,
+	// the writers beginning before it conflict with it
+	kept, dropped = Prune(versions, []uint64{1})
+	assert.Equal(t, versions[:1], kept)
+	assert.Equal(t, versions[1:], dropped)
+
+	kept, dropped = Prune(versions, []uint64{8})
+	assert.Empty(t, kept)
+	assert.ElementsMatch(t, versions, dropped)
+}
@@ -0,0 +1,226 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+const DEFAULT_BUFFER_POOL_SIZE = 256
+
+type BufferPoolStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// Frame holds a page in memory. The decoded node is cached next to the page
+// data so hot nodes are not decoded on every read.
+type Frame struct {
+	pageId uint32
+	// guards the data and the decoded node, readers holding the same frame
+	// decode it concurrently while Put replaces the data
+	lock    sync.Mutex
+	data    []byte
+	node    node.Node
+	dirty   bool
+	pins    uint32
+	element *list.Element
+}
+
+func (f *Frame) GetPageId() uint32 {
+	return f.pageId
+}
+
+// GetData returns the page data, it must not be modified by the caller
+func (f *Frame) GetData() []byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.data
+}
+
+// GetNode decodes the node stored in the page, it must not be modified by the
+// caller
+func (f *Frame) GetNode() (node.Node, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.node == nil {
+		decodedNode, decodeErr := DecodeNode(f.data)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		f.node = decodedNode
+	}
+
+	return f.node, nil
+}
+
+// BufferPool keeps a bounded number of pages in memory. Frames are evicted in
+// least recently used order, pinned frames are never evicted and dirty frames
+// are written back to the file before they are evicted. The pool grows past
+// its capacity while all of its frames are pinned and when pages are put,
+// the extra frames are evicted by the following misses.
+type BufferPool struct {
+	mu        sync.Mutex
+	capacity  int
+	frames    map[uint32]*Frame
+	lru       *list.List
+	readPage  func(pageId uint32) ([]byte, error)
+	writePage func(pageId uint32, data []byte) error
+	stats     BufferPoolStats
+}
+
+func NewBufferPool(capacity int, readPage func(uint32) ([]byte, error), writePage func(uint32, []byte) error) *BufferPool {
+	return &BufferPool{
+		capacity:  max(capacity, 1),
+		frames:    make(map[uint32]*Frame),
+		lru:       list.New(),
+		readPage:  readPage,
+		writePage: writePage,
+	}
+}
+
+// Fetch returns the pinned frame of the page, reading the page from the file
+// when it is not in memory. Every fetch has to be followed by Unpin.
+func (b *BufferPool) Fetch(pageId uint32) (*Frame, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if frame, ok := b.frames[pageId]; ok {
+		b.stats.Hits += 1
+		b.pin(frame)
+		return frame, nil
+	}
+
+	b.stats.Misses += 1
+	data, readErr := b.readPage(pageId)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	frame, frameErr := b.newFrame(pageId, data)
+	if frameErr != nil {
+		return nil, frameErr
+	}
+
+	b.pin(frame)
+	return frame, nil
+}
+
+func (b *BufferPool) Unpin(frame *Frame) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if frame.pins > 0 {
+		frame.pins -= 1
+	}
+}
+
+// Put replaces the content of the page and marks it dirty, the page is
+// written to the file when it is evicted or flushed. Put never evicts and
+// can't fail, the committed pages are installed after they are logged. The
+// pool shrinks back to its capacity on the following misses.
+func (b *BufferPool) Put(pageId uint32, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	frame, ok := b.frames[pageId]
+	if !ok {
+		frame = b.addFrame(pageId, data)
+	}
+
+	frame.lock.Lock()
+	frame.data = data
+	frame.node = nil
+	frame.lock.Unlock()
+	frame.dirty = true
+	b.lru.MoveToFront(frame.element)
+}
+
+// Flush writes all dirty frames back to the file
+func (b *BufferPool) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, frame := range b.frames {
+		if err := b.writeBack(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *BufferPool) Stats() BufferPoolStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stats
+}
+
+func (b *BufferPool) pin(frame *Frame) {
+	frame.pins += 1
+	b.lru.MoveToFront(frame.element)
+}
+
+func (b *BufferPool) newFrame(pageId uint32, data []byte) (*Frame, error) {
+	for len(b.frames) >= b.capacity {
+		evicted, evictErr := b.evict()
+		if evictErr != nil {
+			return nil, evictErr
+		}
+
+		if !evicted {
+			break
+		}
+	}
+
+	return b.addFrame(pageId, data), nil
+}
+
+func (b *BufferPool) addFrame(pageId uint32, data []byte) *Frame {
+	frame := &Frame{pageId: pageId, data: data}
+	frame.element = b.lru.PushFront(frame)
+	b.frames[pageId] = frame
+
+	return frame
+}
+
+// evict removes the least recently used frame which is not pinned, it
+// reports whether there was one
+func (b *BufferPool) evict() (bool, error) {
+	for element := b.lru.Back(); element != nil; element = element.Prev() {
+		frame := element.Value.(*Frame)
+		if frame.pins > 0 {
+			continue
+		}
+
+		if err := b.writeBack(frame); err != nil {
+			return false, fmt.Errorf("failed to evict page %d: %w", frame.pageId, err)
+		}
+
+		b.lru.Remove(element)
+		delete(b.frames, frame.pageId)
+		b.stats.Evictions += 1
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (b *BufferPool) writeBack(frame *Frame) error {
+	if !frame.dirty {
+		return nil
+	}
+
+	if err := b.writePage(frame.pageId, frame.data); err != nil {
+		return err
+	}
+
+	frame.dirty = false
+	return nil
+}
@@ -0,0 +1,183 @@
+package pager
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePageStore struct {
+	pages  map[uint32][]byte
+	reads  int
+	writes int
+	// error returned by the writes when set
+	writeErr error
+}
+
+func newFakePageStore() *fakePageStore {
+	return &fakePageStore{pages: make(map[uint32][]byte)}
+}
+
+func (s *fakePageStore) read(pageId uint32) ([]byte, error) {
+	s.reads += 1
+	data := make([]byte, DEFAULT_PAGE_SIZE)
+	copy(data, s.pages[pageId])
+	return data, nil
+}
+
+func (s *fakePageStore) write(pageId uint32, data []byte) error {
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+
+	s.writes += 1
+	stored := make([]byte, DEFAULT_PAGE_SIZE)
+	copy(stored, data)
+	s.pages[pageId] = stored
+	return nil
+}
+
+func TestBufferPoolFetchHitsAndMisses(t *testing.T) {
+	store := newFakePageStore()
+	pool := NewBufferPool(2, store.read, store.write)
+
+	frame, err := pool.Fetch(0)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+
+	frame, err = pool.Fetch(0)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+
+	assert.Equal(t, 1, store.reads)
+	assert.Equal(t, BufferPoolStats{Hits: 1, Misses: 1}, pool.Stats())
+}
+
+func TestBufferPoolEvictsLeastRecentlyUsed(t *testing.T) {
+	store := newFakePageStore()
+	pool := NewBufferPool(2, store.read, store.write)
+
+	for _, pageId := range []uint32{0, 1, 0, 2} {
+		frame, err := pool.Fetch(pageId)
+		assert.Nil(t, err)
+		pool.Unpin(frame)
+	}
+
+	// page 1 was the least recently used
+	_, page0Cached := pool.frames[0]
+	_, page1Cached := pool.frames[1]
+	assert.True(t, page0Cached)
+	assert.False(t, page1Cached)
+	assert.Equal(t, uint64(1), pool.Stats().Evictions)
+}
+
+func TestBufferPoolWritesBackDirtyPages(t *testing.T) {
+	store := newFakePageStore()
+	pool := NewBufferPool(1, store.read, store.write)
+
+	data := make([]byte, DEFAULT_PAGE_SIZE)
+	data[0] = 42
+	pool.Put(0, data)
+	assert.Equal(t, 0, store.writes)
+
+	// fetching another page evicts the dirty one
+	frame, err := pool.Fetch(1)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+
+	assert.Equal(t, 1, store.writes)
+	assert.Equal(t, byte(42), store.pages[0][0])
+
+	// flushing doesn't write clean pages
+	assert.Nil(t, pool.Flush())
+	assert.Equal(t, 1, store.writes)
+}
+
+func TestBufferPoolDoesNotEvictPinnedPages(t *testing.T) {
+	store := newFakePageStore()
+	pool := NewBufferPool(2, store.read, store.write)
+
+	pinned, err := pool.Fetch(0)
+	assert.Nil(t, err)
+
+	frame, err := pool.Fetch(1)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+
+	frame, err = pool.Fetch(2)
+	assert.Nil(t, err)
+
+	_, page0Cached := pool.frames[0]
+	assert.True(t, page0Cached)
+
+	// both remaining frames are pinned, the pool grows until they are
+	// unpinned
+	extra, err := pool.Fetch(3)
+	assert.Nil(t, err)
+	assert.Len(t, pool.frames, 3)
+	pool.Put(5, make([]byte, 8))
+	assert.Len(t, pool.frames, 4)
+
+	pool.Unpin(pinned)
+	pool.Unpin(frame)
+	pool.Unpin(extra)
+	frame, err = pool.Fetch(4)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+	assert.Len(t, pool.frames, 2)
+}
+
+func TestBufferPoolPutDoesNotEvict(t *testing.T) {
+	store := newFakePageStore()
+	pool := NewBufferPool(1, store.read, store.write)
+
+	// the dirty page can't be written back, putting another page still works
+	pool.Put(0, make([]byte, 8))
+	store.writeErr = errors.New("disk full")
+	pool.Put(1, make([]byte, 8))
+	assert.Len(t, pool.frames, 2)
+
+	_, err := pool.Fetch(2)
+	assert.ErrorIs(t, err, store.writeErr)
+
+	store.writeErr = nil
+	frame, err := pool.Fetch(2)
+	assert.Nil(t, err)
+	pool.Unpin(frame)
+	assert.Len(t, pool.frames, 1)
+	assert.Equal(t, 2, store.writes)
+}
+
+func TestPagerReadsThroughBufferPool(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "buffer_pool.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPagerWithOptions(dbFileName, &Options{BufferPoolSize: 2})
+	assert.Nil(t, pagerErr)
+
+	for i := 0; i < 4; i++ {
+		pageData := make([]byte, DEFAULT_PAGE_SIZE)
+		pageData[0] = byte(i)
+		_, err := pager.WriteNewPage(pageData)
+		assert.Nil(t, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		pageData, err := pager.ReadPage(uint32(i))
+		assert.Nil(t, err)
+		assert.Equal(t, byte(i), pageData[0])
+	}
+
+	assert.Nil(t, pager.CloseFile())
+
+	reopened, reopenErr := NewPager(dbFileName)
+	assert.Nil(t, reopenErr)
+	defer reopened.CloseFile()
+
+	pageData, err := reopened.ReadPage(3)
+	assert.Nil(t, err)
+	assert.Equal(t, byte(3), pageData[0])
+}
@@ -0,0 +1,27 @@
+package pager
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// every page ends with a CRC32C checksum of the rest of the page, it is set
+// when the page is written to the file and verified when it is read back
+const PAGE_CHECKSUM_SIZE = 4
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func checksum(data []byte) uint32 {
+	return crc32.Checksum(data, crc32cTable)
+}
+
+// setTrailingChecksum stores the checksum of the data in its last bytes
+func setTrailingChecksum(data []byte) {
+	checksumOffset := len(data) - PAGE_CHECKSUM_SIZE
+	binary.LittleEndian.PutUint32(data[checksumOffset:], checksum(data[:checksumOffset]))
+}
+
+func hasValidTrailingChecksum(data []byte) bool {
+	checksumOffset := len(data) - PAGE_CHECKSUM_SIZE
+	return binary.LittleEndian.Uint32(data[checksumOffset:]) == checksum(data[:checksumOffset])
+}
@@ -0,0 +1,156 @@
+package pager
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksumDetectsCorruptPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "checksum_page.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	for i := 0; i < 2; i++ {
+		pageData := pager.NewPageBuffer()
+		pageData[0] = byte(i)
+		_, err := pager.WriteNewPage(pageData)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, pager.CloseFile())
+
+	// flip a bit in the second page
+	file, fileErr := os.OpenFile(dbFileName, os.O_RDWR, 0644)
+	assert.Nil(t, fileErr)
+	_, writeErr := file.WriteAt([]byte{0x80}, pager.PageFileOffset(1)+10)
+	assert.Nil(t, writeErr)
+	assert.Nil(t, file.Close())
+
+	reopened, reopenErr := NewPager(dbFileName)
+	assert.Nil(t, reopenErr)
+	defer reopened.CloseFile()
+
+	_, readErr := reopened.ReadPage(0)
+	assert.Nil(t, readErr)
+
+	_, readErr = reopened.ReadPage(1)
+	var corruptPageErr *ErrCorruptPage
+	assert.True(t, errors.As(readErr, &corruptPageErr))
+	assert.Equal(t, uint32(1), corruptPageErr.PageId)
+
+	_, readNodeErr := reopened.ReadPagedNode(1)
+	assert.True(t, errors.As(readNodeErr, &corruptPageErr))
+}
+
+func TestChecksumDetectsCorruptHeader(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "checksum_header.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	assert.Nil(t, pager.CloseFile())
+
+	// corrupt the header and its copy
+	file, fileErr := os.OpenFile(dbFileName, os.O_RDWR, 0644)
+	assert.Nil(t, fileErr)
+	for _, offset := range []int64{8, DATABASE_HEADER_COPY_OFFSET + 8} {
+		_, writeErr := file.WriteAt([]byte{0xff}, offset)
+		assert.Nil(t, writeErr)
+	}
+	assert.Nil(t, file.Close())
+
+	_, reopenErr := NewPager(dbFileName)
+	assert.ErrorIs(t, reopenErr, ErrCorruptHeader)
+}
+
+func TestChecksumRestoresHeaderFromCopy(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "checksum_header.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	_, writeErr := pager.WriteNewPage(pager.NewPageBuffer())
+	assert.Nil(t, writeErr)
+	assert.Nil(t, pager.CloseFile())
+
+	file, fileErr := os.OpenFile(dbFileName, os.O_RDWR, 0644)
+	assert.Nil(t, fileErr)
+	_, tearErr := file.WriteAt([]byte{0xff}, 8)
+	assert.Nil(t, tearErr)
+	assert.Nil(t, file.Close())
+
+	reopened, reopenErr := NewPager(dbFileName)
+	assert.Nil(t, reopenErr)
+	defer reopened.CloseFile()
+	assert.Equal(t, uint32(1), reopened.PageCount())
+}
+
+// tearHeader corrupts the header and its copy
+func tearHeader(t *testing.T, pager *Pager) {
+	for _, offset := range []int64{8, DATABASE_HEADER_COPY_OFFSET + 8} {
+		_, tearErr := pager.file.WriteAt([]byte{0xff}, offset)
+		assert.Nil(t, tearErr)
+	}
+}
+
+func TestChecksumRestoresHeaderFromWal(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "checksum_header_wal.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	_, writeErr := pager.WriteNewPage(pager.NewPageBuffer())
+	assert.Nil(t, writeErr)
+
+	// tear the header while the committed transaction is still in the wal
+	tearHeader(t, pager)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.Equal(t, uint32(1), recovered.PageCount())
+}
+
+func TestChecksumRestoresHeaderWithLargePagesFromWal(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "checksum_header_wal.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	options := DefaultOptions()
+	options.PageSize = 65536
+	pager, pagerErr := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pagerErr)
+
+	pageData := pager.NewPageBuffer()
+	pageData[0] = 7
+	_, writeErr := pager.WriteNewPage(pageData)
+	assert.Nil(t, writeErr)
+
+	// the page size is restored from the logged header
+	tearHeader(t, pager)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.Equal(t, uint32(65536), recovered.PageSize())
+	data, readErr := recovered.ReadPage(0)
+	assert.Nil(t, readErr)
+	assert.Equal(t, byte(7), data[0])
+}
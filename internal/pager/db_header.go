@@ -0,0 +1,171 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/utils"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const DATABASE_HEADER_SIZE = 100
+const MAGIC_STRING = "my db"
+const MAX_COMPARATOR_NAME_SIZE = 32
+
+// the header is stored in page 0 of the file, a copy of it is kept in the
+// next disk sector so a torn header write can be recovered from the other
+const DATABASE_HEADER_COPY_OFFSET = 512
+
+// layout of the file, files written before the version was introduced read
+// as LegacyFileFormatVersion and store the pages right after the header
+const (
+	LegacyFileFormatVersion uint32 = iota
+	PageAlignedFileFormatVersion
+)
+
+const FILE_FORMAT_VERSION = PageAlignedFileFormatVersion
+
+type DatabaseHeader struct {
+	MagicString         [len(MAGIC_STRING)]byte
+	PageSizeBytes       uint32
+	PageCount           uint32
+	RootPageId          uint32
+	RootNodeInitialized bool
+	// head of the free list, only valid when there are free pages
+	FreeListTrunkPageId uint32
+	FreePageCount       uint32
+	// name of the comparator the tree is ordered by, zero padded
+	ComparatorName [MAX_COMPARATOR_NAME_SIZE]byte
+	FormatVersion  uint32
+	// timestamp of the last committed version of the values, zero in files
+	// written before the values were versioned
+	CommitTimestamp uint64
+	// page listing the overflow chains of unfinished writes, zero when there
+	// are none
+	PendingOverflowPageId uint32
+}
+
+func NewDefaultDatabaseHeader() *DatabaseHeader {
+	header := &DatabaseHeader{
+		PageSizeBytes:       4096,
+		PageCount:           0,
+		RootPageId:          0,
+		RootNodeInitialized: false,
+		FreeListTrunkPageId: 0,
+		FreePageCount:       0,
+		FormatVersion:       FILE_FORMAT_VERSION,
+	}
+
+	copy(header.MagicString[:], MAGIC_STRING)
+	copy(header.ComparatorName[:], node.BytewiseComparator.Name())
+
+	return header
+}
+
+// validate checks the fields the layout of the file depends on
+func (h *DatabaseHeader) validate() error {
+	if h.FormatVersion > FILE_FORMAT_VERSION {
+		return fmt.Errorf("%w: %d", ErrUnsupportedFileFormat, h.FormatVersion)
+	}
+
+	return validatePageSize(h.PageSizeBytes)
+}
+
+func (h *DatabaseHeader) GetComparatorName() string {
+	return string(bytes.TrimRight(h.ComparatorName[:], "\x00"))
+}
+
+// ReadFromFile returns the header of the file, the copy is used when the
+// header is corrupted
+func ReadFromFile(file *os.File) (*DatabaseHeader, error) {
+	header, headerErr := readHeaderAt(file, 0)
+	if !errors.Is(headerErr, ErrCorruptHeader) {
+		return header, headerErr
+	}
+
+	// legacy files have no copy, their pages start right after the header
+	headerCopy, copyErr := readHeaderAt(file, DATABASE_HEADER_COPY_OFFSET)
+	if copyErr != nil || headerCopy.FormatVersion == LegacyFileFormatVersion {
+		return nil, ErrCorruptHeader
+	}
+
+	return headerCopy, nil
+}
+
+func readHeaderAt(file *os.File, offset int64) (*DatabaseHeader, error) {
+	buf := make([]byte, DATABASE_HEADER_SIZE)
+	if _, err := file.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("failed to read database header from file: %v", err)
+	}
+
+	return decodeDatabaseHeader(buf)
+}
+
+func decodeDatabaseHeader(buf []byte) (*DatabaseHeader, error) {
+	var header DatabaseHeader
+	reader := bytes.NewReader(buf)
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse header data: %v", err)
+	}
+
+	if !hasValidTrailingChecksum(buf) && !isUnchecksummedLegacyHeader(&header, buf) {
+		return nil, ErrCorruptHeader
+	}
+
+	// legacy files written before the comparator was stored are ordered
+	// bytewise
+	if header.FormatVersion == LegacyFileFormatVersion && header.GetComparatorName() == "" {
+		copy(header.ComparatorName[:], node.BytewiseComparator.Name())
+	}
+
+	return &header, nil
+}
+
+// isUnchecksummedLegacyHeader reports whether the header was written before
+// the header checksum was introduced, the checksum bytes of these headers
+// are zero
+func isUnchecksummedLegacyHeader(header *DatabaseHeader, buf []byte) bool {
+	checksumOffset := len(buf) - PAGE_CHECKSUM_SIZE
+	return string(header.MagicString[:]) == MAGIC_STRING &&
+		header.FormatVersion == LegacyFileFormatVersion &&
+		binary.LittleEndian.Uint32(buf[checksumOffset:]) == 0
+}
+
+func (h *DatabaseHeader) encode() ([]byte, error) {
+	buf := make([]byte, DATABASE_HEADER_SIZE)
+	writer := utils.NewFixedSizeSliceWriter(buf)
+	if err := binary.Write(writer, binary.LittleEndian, h); err != nil {
+		return nil, fmt.Errorf("failed to encode database header: %v", err)
+	}
+	setTrailingChecksum(buf)
+
+	return buf, nil
+}
+
+// WriteToFile writes the header and then its copy, each write is flushed
+// before the next one so at most one of them can be torn
+func (h *DatabaseHeader) WriteToFile(file *os.File) error {
+	data, encodeErr := h.encode()
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	offsets := []int64{0, DATABASE_HEADER_COPY_OFFSET}
+	if h.FormatVersion == LegacyFileFormatVersion {
+		offsets = offsets[:1]
+	}
+
+	for _, offset := range offsets {
+		if _, err := file.WriteAt(data, offset); err != nil {
+			return fmt.Errorf("failed to write database header into the file: %v", err)
+		}
+
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("failed to flush database header into the file: %v", err)
+		}
+	}
+
+	return nil
+}
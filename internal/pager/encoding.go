@@ -0,0 +1,61 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/utils"
+	"fmt"
+)
+
+func EncodeNode(node node.Node, pageSize uint32) ([]byte, error) {
+	buf := make([]byte, pageSize)
+	writer := utils.NewFixedSizeSliceWriter(buf)
+
+	headerData, headerErr := node.GetHeader().Encode()
+	if headerErr != nil {
+		return nil, fmt.Errorf("failed to encode leaf node header: %w", headerErr)
+	}
+
+	if _, err := writer.Write(headerData); err != nil {
+		return nil, fmt.Errorf("failed to write leaf node header: %w", err)
+	}
+
+	if _, err := writer.Write(node.GetBuffer()); err != nil {
+		return nil, fmt.Errorf("failed to write leaf node buffer: %w", err)
+	}
+
+	return buf, nil
+}
+
+func DecodeNode(buf []byte) (node.Node, error) {
+	headerData := buf[:node.NODE_HEADER_SIZE]
+	header, headerErr := node.NewNodeHeaderFromBuffer(headerData)
+	if headerErr != nil {
+		return nil, fmt.Errorf("failed to decode node: %w", headerErr)
+	}
+
+	// the buffer holds a whole page
+	nodeEnd := node.NODE_HEADER_SIZE + int(header.NodeSize)
+	if nodeEnd > len(buf)-PAGE_CHECKSUM_SIZE || header.FreeSpaceStartOffset > header.FreeSpaceEndOffset || header.FreeSpaceEndOffset > header.NodeSize || header.FragmentedBytes > header.NodeSize {
+		return nil, fmt.Errorf("failed to decode node: invalid node header")
+	}
+
+	// nodes of older versions are rebuilt in memory, they are rewritten in
+	// the current format the next time they are written
+	if header.FormatVersion != node.NODE_FORMAT_VERSION {
+		upgraded, upgradeErr := node.UpgradeNode(header, buf[node.NODE_HEADER_SIZE:nodeEnd])
+		if upgradeErr != nil {
+			return nil, fmt.Errorf("failed to decode node: %w", upgradeErr)
+		}
+
+		return upgraded, nil
+	}
+
+	switch header.NodeType {
+	case node.LeafNodeType:
+		return node.NewLeafNode(header, buf[node.NODE_HEADER_SIZE:nodeEnd]), nil
+	case node.InternalNodeType:
+		return node.NewInternalNode(header, buf[node.NODE_HEADER_SIZE:nodeEnd]), nil
+	default:
+		return nil, fmt.Errorf("unexpected node type: %v", header.NodeType)
+	}
+}
@@ -0,0 +1,28 @@
+package pager
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTransactionInProgress = errors.New("transaction already in progress")
+var ErrNoTransaction = errors.New("no transaction in progress")
+var ErrReadOnly = errors.New("pager is read only")
+var ErrCorruptHeader = errors.New("database header is corrupted")
+var ErrComparatorMismatch = errors.New("database was created with a different comparator")
+var ErrUnsupportedFileFormat = errors.New("unsupported database file format")
+var ErrInvalidPageSize = fmt.Errorf("page size must be a power of two between %d and %d", MIN_PAGE_SIZE, MAX_PAGE_SIZE)
+var ErrOverflowTooLarge = errors.New("value is larger than 4GB")
+var ErrInvalidSeek = errors.New("invalid seek")
+var ErrTooManyPendingOverflows = errors.New("too many unfinished overflow writes")
+var ErrComparatorNameTooLong = fmt.Errorf("comparator name is longer than %d bytes", MAX_COMPARATOR_NAME_SIZE)
+
+// ErrCorruptPage is returned when the content of a page read from the file
+// does not match its checksum
+type ErrCorruptPage struct {
+	PageId uint32
+}
+
+func (e *ErrCorruptPage) Error() string {
+	return fmt.Sprintf("page %d is corrupted", e.PageId)
+}
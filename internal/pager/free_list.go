@@ -0,0 +1,200 @@
+package pager
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Freed pages are tracked in a linked list of trunk pages. Each trunk page
+// stores the ids of freed pages and the id of the next trunk page, trunk
+// pages are free pages themselves and are reused once they are empty. Pages
+// retained for open views stay in the free list until they are released.
+const FREE_LIST_TRUNK_HEADER_SIZE = 8
+
+// next trunk page id u32, count u32, page ids u32
+type freeListTrunk struct {
+	NextTrunkPageId uint32
+	PageIds         []uint32
+}
+
+// freeListTrunkCapacity returns the number of page ids a trunk page holds
+func (p *Pager) freeListTrunkCapacity() uint32 {
+	return (p.usableSize() - FREE_LIST_TRUNK_HEADER_SIZE) / 4
+}
+
+func (p *Pager) readFreeListTrunk(pageId uint32) (*freeListTrunk, error) {
+	pageData, readErr := p.ReadPage(pageId)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	count := binary.LittleEndian.Uint32(pageData[4:8])
+	if count > p.freeListTrunkCapacity() {
+		return nil, fmt.Errorf("failed to decode free list trunk %d: invalid count %d", pageId, count)
+	}
+
+	trunk := &freeListTrunk{
+		NextTrunkPageId: binary.LittleEndian.Uint32(pageData[0:4]),
+		PageIds:         make([]uint32, count),
+	}
+	for index := range trunk.PageIds {
+		offset := FREE_LIST_TRUNK_HEADER_SIZE + index*4
+		trunk.PageIds[index] = binary.LittleEndian.Uint32(pageData[offset:(offset + 4)])
+	}
+
+	return trunk, nil
+}
+
+func (p *Pager) writeFreeListTrunk(pageId uint32, trunk *freeListTrunk) error {
+	pageData := p.NewPageBuffer()
+	binary.LittleEndian.PutUint32(pageData[0:4], trunk.NextTrunkPageId)
+	binary.LittleEndian.PutUint32(pageData[4:8], uint32(len(trunk.PageIds)))
+	for index, freePageId := range trunk.PageIds {
+		offset := FREE_LIST_TRUNK_HEADER_SIZE + index*4
+		binary.LittleEndian.PutUint32(pageData[offset:(offset+4)], freePageId)
+	}
+
+	return p.WritePage(pageId, pageData)
+}
+
+// AllocatePage returns the id of a page that can be written, reusing freed
+// pages before growing the file
+func (p *Pager) AllocatePage() (uint32, error) {
+	var pageId uint32
+	txErr := p.Atomic(func() error {
+		var allocateErr error
+		pageId, allocateErr = p.allocatePage()
+		return allocateErr
+	})
+	if txErr != nil {
+		return 0, txErr
+	}
+
+	return pageId, nil
+}
+
+func (p *Pager) allocatePage() (uint32, error) {
+	pageId, allocateErr := p.allocateFreePage()
+	if allocateErr != nil {
+		return 0, allocateErr
+	}
+
+	p.tx.allocated[pageId] = true
+	return pageId, nil
+}
+
+func (p *Pager) allocateFreePage() (uint32, error) {
+	p.lockAllocator()
+	if p.header.FreePageCount == 0 {
+		return p.growFile(), nil
+	}
+
+	trunkPageId := p.header.FreeListTrunkPageId
+	trunk, trunkErr := p.readFreeListTrunk(trunkPageId)
+	if trunkErr != nil {
+		return 0, trunkErr
+	}
+
+	var allocatedPageId uint32
+	if len(trunk.PageIds) > 0 {
+		index := len(trunk.PageIds) - 1
+		for index >= 0 && p.shared.snapshots.isRetained(trunk.PageIds[index]) {
+			index -= 1
+		}
+
+		// the pages of the head trunk are still read by views
+		if index < 0 {
+			return p.growFile(), nil
+		}
+
+		allocatedPageId = trunk.PageIds[index]
+		trunk.PageIds = append(trunk.PageIds[:index], trunk.PageIds[index+1:]...)
+		if err := p.writeFreeListTrunk(trunkPageId, trunk); err != nil {
+			return 0, err
+		}
+	} else {
+		// the empty trunk page is reused, the next trunk becomes the head
+		allocatedPageId = trunkPageId
+		p.header.FreeListTrunkPageId = trunk.NextTrunkPageId
+	}
+
+	p.header.FreePageCount -= 1
+	return allocatedPageId, nil
+}
+
+func (p *Pager) growFile() uint32 {
+	newPageId := p.header.PageCount
+	p.header.PageCount += 1
+	return newPageId
+}
+
+// FreePage returns the page to the free list so it can be allocated again.
+// Committed pages are returned when the transaction commits, until then
+// views may read them.
+func (p *Pager) FreePage(pageId uint32) error {
+	return p.Atomic(func() error {
+		return p.freePage(pageId)
+	})
+}
+
+func (p *Pager) freePage(pageId uint32) error {
+	p.lockAllocator()
+	if !(pageId < p.header.PageCount) {
+		return fmt.Errorf("failed to free page %d: page does not exist", pageId)
+	}
+
+	if !p.tx.allocated[pageId] {
+		p.tx.freed = append(p.tx.freed, pageId)
+		return nil
+	}
+
+	delete(p.tx.allocated, pageId)
+	return p.releasePage(pageId, false)
+}
+
+// releasePage adds the page to the free list. A retained page is only listed
+// in a trunk since views may still read it, it is never written.
+func (p *Pager) releasePage(pageId uint32, retain bool) error {
+	p.lockAllocator()
+
+	if p.header.FreePageCount > 0 {
+		trunkPageId := p.header.FreeListTrunkPageId
+		trunk, trunkErr := p.readFreeListTrunk(trunkPageId)
+		if trunkErr != nil {
+			return trunkErr
+		}
+
+		if uint32(len(trunk.PageIds)) < p.freeListTrunkCapacity() {
+			trunk.PageIds = append(trunk.PageIds, pageId)
+			if err := p.writeFreeListTrunk(trunkPageId, trunk); err != nil {
+				return err
+			}
+
+			p.header.FreePageCount += 1
+			return nil
+		}
+	}
+
+	if retain {
+		// a new trunk page is appended to the file to list the page
+		trunkPageId := p.growFile()
+		trunk := &freeListTrunk{NextTrunkPageId: p.header.FreeListTrunkPageId, PageIds: []uint32{pageId}}
+		if err := p.writeFreeListTrunk(trunkPageId, trunk); err != nil {
+			return err
+		}
+
+		p.header.FreeListTrunkPageId = trunkPageId
+		p.header.FreePageCount += 2
+		return nil
+	}
+
+	// the freed page becomes the new head trunk
+	trunk := &freeListTrunk{NextTrunkPageId: p.header.FreeListTrunkPageId}
+	if err := p.writeFreeListTrunk(pageId, trunk); err != nil {
+		return err
+	}
+
+	p.header.FreeListTrunkPageId = pageId
+	p.header.FreePageCount += 1
+	return nil
+}
@@ -0,0 +1,108 @@
+package pager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagerAllocatePageGrowsFile(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "free_list.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	for expectedPageId := uint32(0); expectedPageId < 3; expectedPageId++ {
+		pageId, allocateErr := pager.AllocatePage()
+		assert.NoError(t, allocateErr)
+		assert.Equal(t, expectedPageId, pageId)
+	}
+
+	assert.Equal(t, uint32(3), pager.PageCount())
+	assert.Zero(t, pager.FreePageCount())
+}
+
+func TestPagerReusesFreedPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "free_list.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	for i := 0; i < 4; i++ {
+		_, writeErr := pager.WriteNewPage(pager.NewPageBuffer())
+		assert.NoError(t, writeErr)
+	}
+
+	assert.NoError(t, pager.FreePage(1))
+	assert.NoError(t, pager.FreePage(3))
+	assert.NoError(t, pager.FreePage(2))
+	assert.Equal(t, uint32(3), pager.FreePageCount())
+
+	allocated := map[uint32]bool{}
+	for i := 0; i < 3; i++ {
+		pageId, allocateErr := pager.AllocatePage()
+		assert.NoError(t, allocateErr)
+		allocated[pageId] = true
+	}
+
+	assert.Equal(t, map[uint32]bool{1: true, 2: true, 3: true}, allocated)
+	assert.Equal(t, uint32(4), pager.PageCount())
+	assert.Zero(t, pager.FreePageCount())
+
+	pageId, allocateErr := pager.AllocatePage()
+	assert.NoError(t, allocateErr)
+	assert.Equal(t, uint32(4), pageId)
+}
+
+func TestPagerFreeListSpansMultipleTrunks(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "free_list.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	pageCount := uint32(2*pager.freeListTrunkCapacity() + 10)
+	for i := uint32(0); i < pageCount; i++ {
+		_, writeErr := pager.WriteNewPage(pager.NewPageBuffer())
+		assert.NoError(t, writeErr)
+	}
+
+	for pageId := uint32(0); pageId < pageCount; pageId++ {
+		assert.NoError(t, pager.FreePage(pageId))
+	}
+	assert.Equal(t, pageCount, pager.FreePageCount())
+
+	// the free list survives reopening the file
+	assert.NoError(t, pager.CloseFile())
+	pager, pagerErr = NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	assert.Equal(t, pageCount, pager.FreePageCount())
+
+	allocated := map[uint32]bool{}
+	for i := uint32(0); i < pageCount; i++ {
+		pageId, allocateErr := pager.AllocatePage()
+		assert.NoError(t, allocateErr)
+		assert.Less(t, pageId, pageCount)
+		allocated[pageId] = true
+	}
+
+	assert.Len(t, allocated, int(pageCount))
+	assert.Equal(t, pageCount, pager.PageCount())
+}
+
+func TestPagerFreeingNonExistingPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "free_list.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	assert.Error(t, pager.FreePage(0))
+}
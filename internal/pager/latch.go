@@ -0,0 +1,116 @@
+package pager
+
+import "sync"
+
+// pageLatches hands out a latch per page, a latch is dropped once no
+// goroutine holds or waits for it
+type pageLatches struct {
+	mu      sync.Mutex
+	latches map[uint32]*pageLatch
+}
+
+type pageLatch struct {
+	sync.RWMutex
+	// goroutines holding or waiting for the latch
+	users int
+}
+
+func newPageLatches() pageLatches {
+	return pageLatches{latches: make(map[uint32]*pageLatch)}
+}
+
+func (l *pageLatches) acquire(pageId uint32) *pageLatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	latch, ok := l.latches[pageId]
+	if !ok {
+		latch = &pageLatch{}
+		l.latches[pageId] = latch
+	}
+	latch.users += 1
+
+	return latch
+}
+
+func (l *pageLatches) release(pageId uint32) *pageLatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	latch := l.latches[pageId]
+	latch.users -= 1
+	if latch.users == 0 {
+		delete(l.latches, pageId)
+	}
+
+	return latch
+}
+
+// LatchPage latches the page shared or exclusively. Writers latch the pages
+// of the tree top down, the pages a writer changes stay latched until its
+// transaction is committed.
+func (p *Pager) LatchPage(pageId uint32, exclusive bool) {
+	latch := p.shared.pageLatches.acquire(pageId)
+	if exclusive {
+		latch.Lock()
+	} else {
+		latch.RLock()
+	}
+}
+
+func (p *Pager) UnlatchPage(pageId uint32, exclusive bool) {
+	latch := p.shared.pageLatches.release(pageId)
+	if exclusive {
+		latch.Unlock()
+	} else {
+		latch.RUnlock()
+	}
+}
+
+// LatchRoot latches the root page id, it is latched before the root page and
+// exclusively by writers which can replace the root. The root page id of the
+// transaction is reloaded since other writers may have replaced the root.
+func (p *Pager) LatchRoot(exclusive bool) {
+	if exclusive {
+		p.shared.rootLatch.Lock()
+	} else {
+		p.shared.rootLatch.RLock()
+	}
+
+	if p.tx == nil || p.tx.rootChanged {
+		return
+	}
+
+	committed := p.committedHeader()
+	p.tx.updateHeaders(p.header, func(header *DatabaseHeader) {
+		header.RootPageId = committed.RootPageId
+		header.RootNodeInitialized = committed.RootNodeInitialized
+	})
+}
+
+func (p *Pager) UnlatchRoot(exclusive bool) {
+	if exclusive {
+		p.shared.rootLatch.Unlock()
+	} else {
+		p.shared.rootLatch.RUnlock()
+	}
+}
+
+// lockAllocator gives the free list to the transaction until it ends. The
+// free list fields of the header are reloaded since other writers may have
+// allocated pages since the transaction began.
+func (p *Pager) lockAllocator() {
+	if p.tx.allocating {
+		return
+	}
+
+	p.shared.allocator.Lock()
+	p.tx.allocating = true
+
+	committed := p.committedHeader()
+	p.tx.updateHeaders(p.header, func(header *DatabaseHeader) {
+		header.PageCount = committed.PageCount
+		header.FreeListTrunkPageId = committed.FreeListTrunkPageId
+		header.FreePageCount = committed.FreePageCount
+	})
+}
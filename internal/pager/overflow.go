@@ -0,0 +1,319 @@
+package pager
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Values too large for a leaf are stored in a chain of overflow pages. Each
+// page starts with the id of the next page and the length of the chunk it
+// holds, the number of pages follows from the length of the value.
+const OVERFLOW_PAGE_HEADER_SIZE = 8
+
+// OverflowPageCapacity returns the number of value bytes an overflow page
+// holds
+func (p *Pager) OverflowPageCapacity() uint32 {
+	return p.usableSize() - OVERFLOW_PAGE_HEADER_SIZE
+}
+
+func (p *Pager) overflowPageCount(length uint32) uint32 {
+	if length == 0 {
+		return 1
+	}
+
+	return (length + p.OverflowPageCapacity() - 1) / p.OverflowPageCapacity()
+}
+
+// overflowChunkLength returns the length of the chunk stored in the page at
+// the index, every page but the last one is full
+func (p *Pager) overflowChunkLength(length uint32, index uint32) uint32 {
+	return min(p.OverflowPageCapacity(), length-index*p.OverflowPageCapacity())
+}
+
+// WriteOverflow stores the value in newly allocated overflow pages and
+// returns the id of the first page
+func (p *Pager) WriteOverflow(data []byte) (uint32, error) {
+	var pageId uint32
+	txErr := p.Atomic(func() error {
+		writer := p.NewOverflowWriter()
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+
+		var closeErr error
+		pageId, _, closeErr = writer.Close()
+		return closeErr
+	})
+	if txErr != nil {
+		return 0, fmt.Errorf("failed to write overflow pages: %w", txErr)
+	}
+
+	return pageId, nil
+}
+
+// ReadOverflow reassembles the value of the given length stored in the chain
+// starting at the given page
+func (p *Pager) ReadOverflow(pageId uint32, length uint32) ([]byte, error) {
+	data := make([]byte, length)
+	if _, err := io.ReadFull(p.NewOverflowReader(pageId, length), data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// FreeOverflow releases the pages of the chain starting at the given page
+func (p *Pager) FreeOverflow(pageId uint32, length uint32) error {
+	return p.Atomic(func() error {
+		for index := uint32(0); index < p.overflowPageCount(length); index++ {
+			nextPageId, _, readErr := p.readOverflowPage(pageId, p.overflowChunkLength(length, index))
+			if readErr != nil {
+				return readErr
+			}
+
+			if err := p.FreePage(pageId); err != nil {
+				return err
+			}
+
+			pageId = nextPageId
+		}
+
+		return nil
+	})
+}
+
+// readOverflowPage returns the id of the next page and the chunk stored in
+// the page, the chunk must have the expected length
+func (p *Pager) readOverflowPage(pageId uint32, chunkLength uint32) (uint32, []byte, error) {
+	pageData, readErr := p.ReadPage(pageId)
+	if readErr != nil {
+		return 0, nil, readErr
+	}
+
+	nextPageId := binary.LittleEndian.Uint32(pageData[0:4])
+	storedLength := binary.LittleEndian.Uint32(pageData[4:8])
+	if storedLength != chunkLength {
+		return 0, nil, fmt.Errorf("failed to read overflow page %d: invalid chunk length %d", pageId, storedLength)
+	}
+
+	return nextPageId, pageData[OVERFLOW_PAGE_HEADER_SIZE:(OVERFLOW_PAGE_HEADER_SIZE + chunkLength)], nil
+}
+
+// OverflowWriter writes a value into a new chain of overflow pages. The pages
+// are kept in memory until Flush or Close writes them through the pager, the
+// writer can't be used after the transaction of a Flush or Close is rolled
+// back.
+type OverflowWriter struct {
+	pager *Pager
+	// ids of the written pages, followed by the id of the page being filled
+	// once the pages before it are written
+	pageIds []uint32
+	written int
+	// pages not written yet, the last one is being filled
+	pages [][]byte
+	// length of the chunk in the page being filled
+	chunkLength uint32
+	length      uint32
+	// the written pages are listed as a pending chain until Close
+	pending bool
+}
+
+func (p *Pager) NewOverflowWriter() *OverflowWriter {
+	return &OverflowWriter{pager: p, pages: [][]byte{p.NewPageBuffer()}}
+}
+
+func (w *OverflowWriter) Write(data []byte) (int, error) {
+	if uint64(w.length)+uint64(len(data)) > math.MaxUint32 {
+		return 0, ErrOverflowTooLarge
+	}
+
+	capacity := w.pager.OverflowPageCapacity()
+	written := 0
+	for written < len(data) {
+		// the next page is only started once there is data to put in it
+		if w.chunkLength == capacity {
+			w.pages = append(w.pages, w.pager.NewPageBuffer())
+			w.chunkLength = 0
+		}
+
+		page := w.pages[len(w.pages)-1]
+		chunkEnd := OVERFLOW_PAGE_HEADER_SIZE + w.chunkLength
+		copied := copy(page[chunkEnd:(OVERFLOW_PAGE_HEADER_SIZE+capacity)], data[written:])
+		w.chunkLength += uint32(copied)
+		w.length += uint32(copied)
+		written += copied
+	}
+
+	return written, nil
+}
+
+// BufferedPages returns the number of pages kept in memory
+func (w *OverflowWriter) BufferedPages() int {
+	return len(w.pages)
+}
+
+// Flush writes the full pages and lists the chain as pending, only the page
+// being filled stays in memory. A pending chain is freed when the database is
+// opened before the chain is closed.
+func (w *OverflowWriter) Flush() error {
+	if len(w.pages) == 1 {
+		return nil
+	}
+
+	return w.pager.Atomic(func() error {
+		if err := w.writePages(len(w.pages) - 1); err != nil {
+			return err
+		}
+
+		w.pending = true
+		return w.pager.setPendingOverflow(w.pageIds[0], uint32(len(w.pageIds)))
+	})
+}
+
+// Close writes the pages kept in memory and returns the id of the first page
+// and the length of the value
+func (w *OverflowWriter) Close() (uint32, uint32, error) {
+	closeErr := w.pager.Atomic(func() error {
+		if err := w.writePages(len(w.pages)); err != nil {
+			return err
+		}
+
+		if w.pending {
+			return w.pager.removePendingOverflow(w.pageIds[0])
+		}
+
+		return nil
+	})
+	if closeErr != nil {
+		return 0, 0, closeErr
+	}
+
+	return w.pageIds[0], w.length, nil
+}
+
+// PageIds returns the pages allocated by the writer so far
+func (w *OverflowWriter) PageIds() []uint32 {
+	return w.pageIds
+}
+
+// writePages writes the first count pages kept in memory, the ids are
+// allocated up to the page following them so they can be linked
+func (w *OverflowWriter) writePages(count int) error {
+	for len(w.pageIds) < w.written+min(count+1, len(w.pages)) {
+		pageId, allocateErr := w.pager.AllocatePage()
+		if allocateErr != nil {
+			return allocateErr
+		}
+
+		w.pageIds = append(w.pageIds, pageId)
+	}
+
+	for index := 0; index < count; index++ {
+		// every page but the last one is full
+		var nextPageId uint32
+		chunkLength := w.chunkLength
+		if index+1 < len(w.pages) {
+			nextPageId = w.pageIds[w.written+index+1]
+			chunkLength = w.pager.OverflowPageCapacity()
+		}
+
+		page := w.pages[index]
+		binary.LittleEndian.PutUint32(page[0:4], nextPageId)
+		binary.LittleEndian.PutUint32(page[4:8], chunkLength)
+		if err := w.pager.WritePage(w.pageIds[w.written+index], page); err != nil {
+			return err
+		}
+	}
+
+	w.pages = w.pages[count:]
+	w.written += count
+	return nil
+}
+
+// OverflowReader reads a value stored in a chain of overflow pages one page
+// at a time. The ids of the pages passed are kept so seeking backwards does
+// not walk the chain again.
+type OverflowReader struct {
+	pager   *Pager
+	pageIds []uint32
+	length  uint32
+	offset  int64
+}
+
+func (p *Pager) NewOverflowReader(pageId uint32, length uint32) *OverflowReader {
+	return &OverflowReader{pager: p, pageIds: []uint32{pageId}, length: length}
+}
+
+// Size returns the length of the value
+func (r *OverflowReader) Size() int64 {
+	return int64(r.length)
+}
+
+func (r *OverflowReader) Read(buf []byte) (int, error) {
+	if r.offset >= int64(r.length) {
+		return 0, io.EOF
+	}
+
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	capacity := int64(r.pager.OverflowPageCapacity())
+	index := uint32(r.offset / capacity)
+	if err := r.walkTo(index); err != nil {
+		return 0, err
+	}
+
+	nextPageId, chunk, readErr := r.pager.readOverflowPage(r.pageIds[index], r.pager.overflowChunkLength(r.length, index))
+	if readErr != nil {
+		return 0, readErr
+	}
+	r.addPage(index, nextPageId)
+
+	read := copy(buf, chunk[r.offset%capacity:])
+	r.offset += int64(read)
+	return read, nil
+}
+
+func (r *OverflowReader) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = r.offset + offset
+	case io.SeekEnd:
+		position = int64(r.length) + offset
+	default:
+		return 0, fmt.Errorf("%w: invalid whence %d", ErrInvalidSeek, whence)
+	}
+
+	if position < 0 {
+		return 0, fmt.Errorf("%w: negative position %d", ErrInvalidSeek, position)
+	}
+
+	r.offset = position
+	return position, nil
+}
+
+// walkTo follows the chain until the id of the page at the index is known
+func (r *OverflowReader) walkTo(index uint32) error {
+	for uint32(len(r.pageIds)) <= index {
+		last := uint32(len(r.pageIds) - 1)
+		nextPageId, _, readErr := r.pager.readOverflowPage(r.pageIds[last], r.pager.overflowChunkLength(r.length, last))
+		if readErr != nil {
+			return readErr
+		}
+		r.addPage(last, nextPageId)
+	}
+
+	return nil
+}
+
+func (r *OverflowReader) addPage(index uint32, nextPageId uint32) {
+	if index+1 == uint32(len(r.pageIds)) && index+1 < r.pager.overflowPageCount(r.length) {
+		r.pageIds = append(r.pageIds, nextPageId)
+	}
+}
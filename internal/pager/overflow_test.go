@@ -0,0 +1,219 @@
+package pager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagerOverflowRoundTrip(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	for _, length := range []int{0, 1, capacity, capacity + 1, 3*capacity + 17} {
+		data := bytes.Repeat([]byte{byte(length)}, length)
+		pageId, writeErr := pager.WriteOverflow(data)
+		assert.NoError(t, writeErr)
+
+		readData, readErr := pager.ReadOverflow(pageId, uint32(length))
+		assert.NoError(t, readErr)
+		assert.Equal(t, data, readData)
+	}
+}
+
+func TestPagerFreeOverflowReleasesPages(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	data := bytes.Repeat([]byte("x"), 2*capacity+1)
+	pageId, writeErr := pager.WriteOverflow(data)
+	assert.NoError(t, writeErr)
+	pageCount := pager.PageCount()
+
+	assert.NoError(t, pager.FreeOverflow(pageId, uint32(len(data))))
+	assert.Equal(t, uint32(3), pager.FreePageCount())
+
+	// freed pages are reused by the next value
+	_, writeErr = pager.WriteOverflow(data)
+	assert.NoError(t, writeErr)
+	assert.Equal(t, pageCount, pager.PageCount())
+	assert.Zero(t, pager.FreePageCount())
+}
+
+func TestPagerReadOverflowInvalidChunkLength(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	pageId, writeErr := pager.WriteOverflow([]byte("data"))
+	assert.NoError(t, writeErr)
+
+	pageData := pager.NewPageBuffer()
+	binary.LittleEndian.PutUint32(pageData[4:8], uint32(capacity+1))
+	assert.NoError(t, pager.WritePage(pageId, pageData))
+
+	_, readErr := pager.ReadOverflow(pageId, 4)
+	assert.Error(t, readErr)
+}
+
+func TestPagerOverflowWriterStreamsChunks(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	writer := pager.NewOverflowWriter()
+
+	var data []byte
+	for i := 0; i < 1000; i++ {
+		chunk := bytes.Repeat([]byte{byte(i)}, 37)
+		written, writeErr := writer.Write(chunk)
+		assert.NoError(t, writeErr)
+		assert.Equal(t, len(chunk), written)
+		data = append(data, chunk...)
+	}
+
+	pageId, length, closeErr := writer.Close()
+	assert.NoError(t, closeErr)
+	assert.Equal(t, uint32(len(data)), length)
+	assert.Len(t, writer.PageIds(), int(pager.overflowPageCount(length)))
+
+	readData, readErr := pager.ReadOverflow(pageId, length)
+	assert.NoError(t, readErr)
+	assert.Equal(t, data, readData)
+}
+
+func TestPagerOverflowReaderSeek(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	data := make([]byte, 4*capacity+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	pageId, writeErr := pager.WriteOverflow(data)
+	assert.NoError(t, writeErr)
+
+	reader := pager.NewOverflowReader(pageId, uint32(len(data)))
+	assert.Equal(t, int64(len(data)), reader.Size())
+
+	// seek past the pages read so far, then backwards
+	for _, offset := range []int{3*capacity + 10, capacity - 5, 0} {
+		position, seekErr := reader.Seek(int64(offset), io.SeekStart)
+		assert.NoError(t, seekErr)
+		assert.Equal(t, int64(offset), position)
+
+		buf := make([]byte, 64)
+		_, readErr := io.ReadFull(reader, buf)
+		assert.NoError(t, readErr)
+		assert.Equal(t, data[offset:offset+64], buf)
+	}
+
+	position, seekErr := reader.Seek(-10, io.SeekEnd)
+	assert.NoError(t, seekErr)
+	assert.Equal(t, int64(len(data)-10), position)
+	tail, readErr := io.ReadAll(reader)
+	assert.NoError(t, readErr)
+	assert.Equal(t, data[len(data)-10:], tail)
+
+	_, seekErr = reader.Seek(-1, io.SeekStart)
+	assert.ErrorIs(t, seekErr, ErrInvalidSeek)
+}
+
+func TestPagerFreesPendingOverflowAfterCrash(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	// two flushed batches and a closed chain, the crash interrupts the first
+	// chain before it is closed
+	pending := pager.NewOverflowWriter()
+	for batch := 0; batch < 2; batch++ {
+		_, writeErr := pending.Write(bytes.Repeat([]byte{byte(batch)}, 5*capacity+10))
+		assert.NoError(t, writeErr)
+		assert.NoError(t, pending.Flush())
+		assert.Equal(t, 1, pending.BufferedPages())
+	}
+	assert.NotZero(t, pager.header.PendingOverflowPageId)
+
+	data := bytes.Repeat([]byte{7}, 3*capacity)
+	pageId, writeErr := pager.WriteOverflow(data)
+	assert.NoError(t, writeErr)
+	pageCount := pager.PageCount()
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.NoError(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	// the pending chain and the page listing it are free again
+	assert.Zero(t, recovered.header.PendingOverflowPageId)
+	assert.Equal(t, pageCount, recovered.PageCount())
+	assert.Equal(t, uint32(len(pending.PageIds())+1), recovered.FreePageCount())
+
+	readData, readErr := recovered.ReadOverflow(pageId, uint32(len(data)))
+	assert.NoError(t, readErr)
+	assert.Equal(t, data, readData)
+}
+
+func TestPagerOverflowWriterClosesPendingChain(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "overflow_chain.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+	capacity := int(pager.OverflowPageCapacity())
+
+	data := make([]byte, 4*capacity+100)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	writer := pager.NewOverflowWriter()
+	for start := 0; start < len(data); start += capacity + 7 {
+		_, writeErr := writer.Write(data[start:min(len(data), start+capacity+7)])
+		assert.NoError(t, writeErr)
+		assert.NoError(t, writer.Flush())
+	}
+
+	pageId, length, closeErr := writer.Close()
+	assert.NoError(t, closeErr)
+	assert.Zero(t, pager.header.PendingOverflowPageId)
+	// the page which listed the chain is free
+	assert.Equal(t, uint32(1), pager.FreePageCount())
+
+	readData, readErr := pager.ReadOverflow(pageId, length)
+	assert.NoError(t, readErr)
+	assert.Equal(t, data, readData)
+}
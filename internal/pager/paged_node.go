@@ -1,6 +1,6 @@
 package pager
 
-import "bricker-db/btree/node"
+import "bricker-db/internal/btree/node"
 
 type PagedNode struct {
 	Page uint32
@@ -0,0 +1,570 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// the page size is chosen when the file is created, it must be a power of
+// two between MIN_PAGE_SIZE and MAX_PAGE_SIZE
+const DEFAULT_PAGE_SIZE = 4096
+const MIN_PAGE_SIZE = 4096
+const MAX_PAGE_SIZE = 65536
+
+type Options struct {
+	// size of the pages of a new file, existing files keep their page size
+	PageSize uint32
+	// number of pages kept in memory
+	BufferPoolSize int
+	// number of logged pages after which the wal is checkpointed
+	WalCheckpointSize int
+	// order of the keys, a database must always be opened with the
+	// comparator it was created with
+	Comparator node.Comparator
+	SyncMode   SyncMode
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		PageSize:          DEFAULT_PAGE_SIZE,
+		BufferPoolSize:    DEFAULT_BUFFER_POOL_SIZE,
+		WalCheckpointSize: DEFAULT_WAL_CHECKPOINT_SIZE,
+		Comparator:        node.BytewiseComparator,
+		SyncMode:          SyncFull,
+	}
+}
+
+// Pager reads and writes the pages of a database file. A pager runs one
+// transaction at a time, concurrent writers use the pagers returned by
+// NewWriter and readers the views returned by BeginRead.
+type Pager struct {
+	file     *os.File
+	header   *DatabaseHeader
+	pageSize uint32
+	// layout of the pages in the file, fixed once the file is opened
+	formatVersion     uint32
+	pool              *BufferPool
+	wal               *Wal
+	walCheckpointSize int
+	comparator        node.Comparator
+	tx                *transaction
+	shared            *sharedState
+	// views returned by BeginRead can't begin transactions
+	readOnly bool
+	// writers returned by NewWriter overwrite the committed pages no view
+	// can read, the other transactions copy the committed pages they change
+	inPlace bool
+	// commit timestamp stored in the header by the next commit
+	commitTimestamp uint64
+}
+
+// sharedState is shared by a pager and the writers and views created from it
+type sharedState struct {
+	// held shared while the committed header is read and exclusively while
+	// a commit installs its pages
+	latch sync.RWMutex
+	// header of the last committed transaction, changed under the commit
+	// lock and the latch
+	committed DatabaseHeader
+	// number of committed transactions, changed under the commit lock
+	seq uint64
+	// serializes the commits so the wal logs them in the order they are
+	// installed
+	commitLock sync.Mutex
+	// held shared by the transactions of the writers overwriting pages in
+	// place, a view waits for them before it freezes the committed pages
+	freeze    sync.RWMutex
+	snapshots snapshots
+	// held by the transaction changing the free list until it ends
+	allocator   sync.Mutex
+	rootLatch   sync.RWMutex
+	pageLatches pageLatches
+}
+
+func NewPager(filePath string) (*Pager, error) {
+	return NewPagerWithOptions(filePath, DefaultOptions())
+}
+
+func NewPagerWithOptions(filePath string, options *Options) (*Pager, error) {
+	// keys are ordered bytewise when no comparator is given
+	if options.Comparator == nil {
+		withComparator := *options
+		withComparator.Comparator = node.BytewiseComparator
+		options = &withComparator
+	}
+
+	if len(options.Comparator.Name()) > MAX_COMPARATOR_NAME_SIZE {
+		return nil, ErrComparatorNameTooLong
+	}
+
+	file, fileErr := os.OpenFile(filePath, os.O_RDWR, 0644)
+
+	if fileErr != nil {
+		if errors.Is(fileErr, os.ErrNotExist) {
+			return initPagerInNewFile(filePath, options)
+		}
+
+		return nil, fileErr
+	}
+
+	// a torn header is restored from the wal when it was logged
+	header, headerErr := ReadFromFile(file)
+	if headerErr != nil && !errors.Is(headerErr, ErrCorruptHeader) {
+		return nil, headerErr
+	}
+
+	if header != nil {
+		if err := header.validate(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	pager, pagerErr := newPager(filePath, file, header, options)
+	if pagerErr != nil {
+		return nil, pagerErr
+	}
+
+	if err := pager.recover(); err != nil {
+		return nil, err
+	}
+
+	if err := pager.header.validate(); err != nil {
+		pager.wal.close()
+		file.Close()
+		return nil, err
+	}
+
+	if name := pager.header.GetComparatorName(); name != options.Comparator.Name() {
+		// the file is left untouched, replayed pages stay in the wal
+		pager.wal.close()
+		file.Close()
+		return nil, fmt.Errorf("%w: opened with %q, created with %q", ErrComparatorMismatch, options.Comparator.Name(), name)
+	}
+
+	// the wal is empty after the recovery, files in an older format are
+	// upgraded before they are used
+	if pager.header.FormatVersion != FILE_FORMAT_VERSION {
+		if err := pager.upgradeFile(); err != nil {
+			pager.wal.close()
+			pager.file.Close()
+			return nil, err
+		}
+	}
+
+	pager.shared.committed = *pager.header
+
+	// the chains of the writes interrupted by a crash are freed
+	if pager.header.PendingOverflowPageId != 0 {
+		if err := pager.Atomic(pager.freePendingOverflows); err != nil {
+			pager.wal.close()
+			pager.file.Close()
+			return nil, err
+		}
+	}
+
+	return pager, nil
+}
+
+func initPagerInNewFile(filePath string, options *Options) (*Pager, error) {
+	pageSize := options.PageSize
+	if pageSize == 0 {
+		pageSize = DEFAULT_PAGE_SIZE
+	}
+
+	if err := validatePageSize(pageSize); err != nil {
+		return nil, err
+	}
+
+	file, fileErr := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	header := NewDefaultDatabaseHeader()
+	header.PageSizeBytes = pageSize
+	header.ComparatorName = [MAX_COMPARATOR_NAME_SIZE]byte{}
+	copy(header.ComparatorName[:], options.Comparator.Name())
+
+	pager, pagerErr := newPager(filePath, file, header, options)
+	if pagerErr != nil {
+		return nil, pagerErr
+	}
+
+	if err := header.WriteToFile(file); err != nil {
+		return nil, err
+	}
+
+	// a log left behind by a removed database must not be replayed
+	if err := pager.wal.truncate(); err != nil {
+		return nil, err
+	}
+
+	pager.shared.committed = *pager.header
+	return pager, nil
+}
+
+func newPager(filePath string, file *os.File, header *DatabaseHeader, options *Options) (*Pager, error) {
+	wal, walErr := openWal(filePath+WAL_FILE_SUFFIX, options.SyncMode)
+	if walErr != nil {
+		return nil, walErr
+	}
+
+	pager := &Pager{
+		file:              file,
+		header:            header,
+		wal:               wal,
+		walCheckpointSize: options.WalCheckpointSize,
+		comparator:        options.Comparator,
+		shared:            &sharedState{pageLatches: newPageLatches(), snapshots: newSnapshots()},
+	}
+	pager.pool = NewBufferPool(options.BufferPoolSize, pager.readPageFromFile, pager.writeBackPage)
+
+	// the page size of a torn header is restored by the recovery
+	if header != nil {
+		pager.pageSize = header.PageSizeBytes
+		pager.formatVersion = header.FormatVersion
+	}
+
+	return pager, nil
+}
+
+// CloseFile checkpoints the wal and closes the database, the wal file is
+// removed once it is empty
+func (p *Pager) CloseFile() error {
+	if err := p.Checkpoint(); err != nil {
+		return err
+	}
+
+	if err := p.wal.close(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(p.wal.file.Name()); err != nil {
+		return fmt.Errorf("failed to remove wal file: %v", err)
+	}
+
+	return p.file.Close()
+}
+
+// BeginRead returns a read only snapshot of the last committed state of the
+// pager. The view does not see the pages of the transaction in progress nor
+// of the transactions committed after it began, the pages it can read are
+// neither overwritten nor reused until it is released with EndRead.
+func (p *Pager) BeginRead() *Pager {
+	// the writers overwriting pages in place finish before the committed
+	// pages are frozen
+	p.shared.freeze.Lock()
+	defer p.shared.freeze.Unlock()
+	p.shared.commitLock.Lock()
+	defer p.shared.commitLock.Unlock()
+
+	header := p.shared.committed
+	view := &Pager{
+		file:          p.file,
+		header:        &header,
+		pageSize:      p.pageSize,
+		formatVersion: p.formatVersion,
+		pool:          p.pool,
+		comparator:    p.comparator,
+		shared:        p.shared,
+		readOnly:      true,
+	}
+	p.shared.snapshots.take(view, p.shared.seq)
+
+	return view
+}
+
+// EndRead releases a view returned by BeginRead, it must not be used
+// afterwards
+func (p *Pager) EndRead() {
+	p.shared.snapshots.release(p)
+}
+
+// NewWriter returns a pager running its own transactions on the same file.
+// Writers commit concurrently, they must latch the pages they read and
+// change until their transaction is committed. A transaction allocating
+// pages holds the free list until it ends, it must not latch pages after
+// the first allocation. Writers overwrite the committed pages no view can
+// read, the views taken while their transactions run wait for them.
+func (p *Pager) NewWriter() *Pager {
+	header := p.committedHeader()
+	return &Pager{
+		file:              p.file,
+		header:            &header,
+		pageSize:          p.pageSize,
+		formatVersion:     p.formatVersion,
+		pool:              p.pool,
+		wal:               p.wal,
+		walCheckpointSize: p.walCheckpointSize,
+		comparator:        p.comparator,
+		shared:            p.shared,
+		inPlace:           true,
+	}
+}
+
+// committedHeader returns a copy of the header of the last committed
+// transaction
+func (p *Pager) committedHeader() DatabaseHeader {
+	p.shared.latch.RLock()
+	defer p.shared.latch.RUnlock()
+
+	return p.shared.committed
+}
+
+// visibleHeader returns the header of the transaction in progress or of the
+// view, other pagers see the last committed header
+func (p *Pager) visibleHeader() DatabaseHeader {
+	if p.tx != nil || p.readOnly {
+		return *p.header
+	}
+
+	return p.committedHeader()
+}
+
+func (p *Pager) BufferPoolStats() BufferPoolStats {
+	return p.pool.Stats()
+}
+
+func (p *Pager) Comparator() node.Comparator {
+	return p.comparator
+}
+
+func (p *Pager) PageSize() uint32 {
+	return p.pageSize
+}
+
+// NodeSize returns the size of the nodes stored in the pages
+func (p *Pager) NodeSize() uint32 {
+	return p.usableSize() - node.NODE_HEADER_SIZE
+}
+
+func (p *Pager) RootNodeInitialized() bool {
+	return p.visibleHeader().RootNodeInitialized
+}
+
+func (p *Pager) RootPageId() uint32 {
+	return p.visibleHeader().RootPageId
+}
+
+func (p *Pager) PageCount() uint32 {
+	return p.visibleHeader().PageCount
+}
+
+// CommitTimestamp returns the timestamp of the last committed version of the
+// values
+func (p *Pager) CommitTimestamp() uint64 {
+	return p.visibleHeader().CommitTimestamp
+}
+
+// SetCommitTimestamp stores the timestamp in the header with the next commit
+// of the pager, the header keeps the largest timestamp of the writers
+func (p *Pager) SetCommitTimestamp(timestamp uint64) {
+	p.commitTimestamp = timestamp
+}
+
+func (p *Pager) FreePageCount() uint32 {
+	return p.visibleHeader().FreePageCount
+}
+
+// ReadPage returns a copy of the page data
+func (p *Pager) ReadPage(pageId uint32) ([]byte, error) {
+	pageData := p.NewPageBuffer()
+	if p.tx != nil {
+		if data, ok := p.tx.pages[pageId]; ok {
+			copy(pageData, data)
+			return pageData, nil
+		}
+	}
+
+	frame, fetchErr := p.pool.Fetch(pageId)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer p.pool.Unpin(frame)
+
+	copy(pageData, frame.GetData())
+
+	return pageData, nil
+}
+
+// WritePage stores a copy of the page data in the current transaction, the
+// write is committed on its own when there is no transaction. The last
+// PAGE_CHECKSUM_SIZE bytes of the page are overwritten by the checksum.
+func (p *Pager) WritePage(pageId uint32, data []byte) error {
+	if len(data) != int(p.pageSize) {
+		return fmt.Errorf("invalid page size: got %d bytes but expected %d bytes", len(data), p.pageSize)
+	}
+
+	pageData := p.NewPageBuffer()
+	copy(pageData, data)
+
+	return p.Atomic(func() error {
+		p.tx.writePage(pageId, pageData)
+		return nil
+	})
+}
+
+func (p *Pager) readPageFromFile(pageId uint32) ([]byte, error) {
+	pageData := p.NewPageBuffer()
+	offset := p.PageFileOffset(pageId)
+	bytesRead, err := p.file.ReadAt(pageData, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page data from file: %v", err)
+	}
+	if bytesRead != int(p.pageSize) {
+		return nil, fmt.Errorf("failed to read the entire page, read %d bytes but expected %d bytes", bytesRead, p.pageSize)
+	}
+
+	if !hasValidTrailingChecksum(pageData) {
+		return nil, &ErrCorruptPage{pageId}
+	}
+
+	return pageData, nil
+}
+
+// writeBackPage writes a committed page into the file, the wal is synced
+// first so the file never holds pages of a transaction that can be lost
+func (p *Pager) writeBackPage(pageId uint32, data []byte) error {
+	if err := p.wal.sync(); err != nil {
+		return err
+	}
+
+	return p.writePageToFile(pageId, data)
+}
+
+func (p *Pager) writePageToFile(pageId uint32, data []byte) error {
+	offset := p.PageFileOffset(pageId)
+
+	pageData := p.NewPageBuffer()
+	copy(pageData, data)
+	setTrailingChecksum(pageData)
+
+	bytesWritten, err := p.file.WriteAt(pageData, offset)
+	if err != nil {
+		return fmt.Errorf("failed to write the page into the file: %v", err)
+	}
+
+	if bytesWritten != int(p.pageSize) {
+		return fmt.Errorf("failed to write the entire page, wrote %d bytes but expected %d bytes", bytesWritten, p.pageSize)
+
+	}
+
+	return nil
+}
+
+func (p *Pager) ReadRootNode() (*PagedNode, error) {
+	return p.ReadPagedNode(p.RootPageId())
+}
+
+// ReadPagedNode returns a copy of the node cached in the buffer pool, it can
+// be modified without affecting the page until it is written back
+func (p *Pager) ReadPagedNode(pageId uint32) (*PagedNode, error) {
+	if p.tx != nil {
+		if _, ok := p.tx.pages[pageId]; ok {
+			pageData, readErr := p.ReadPage(pageId)
+			if readErr != nil {
+				return nil, readErr
+			}
+
+			decodedNode, decodeErr := DecodeNode(pageData)
+			if decodeErr != nil {
+				return nil, decodeErr
+			}
+
+			return &PagedNode{pageId, decodedNode}, nil
+		}
+	}
+
+	frame, fetchErr := p.pool.Fetch(pageId)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer p.pool.Unpin(frame)
+
+	cachedNode, decodeErr := frame.GetNode()
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return &PagedNode{pageId, cachedNode.Clone()}, nil
+}
+
+func (p *Pager) WriteNodeToPage(pageId uint32, node node.Node) error {
+	buf, encodeErr := EncodeNode(node, p.pageSize)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	return p.WritePage(pageId, buf)
+}
+
+func (p *Pager) WriteNewPage(data []byte) (uint32, error) {
+	var newPageId uint32
+	txErr := p.Atomic(func() error {
+		var allocateErr error
+		if newPageId, allocateErr = p.AllocatePage(); allocateErr != nil {
+			return allocateErr
+		}
+
+		return p.WritePage(newPageId, data)
+	})
+	if txErr != nil {
+		return 0, txErr
+	}
+
+	return newPageId, nil
+}
+
+func (p *Pager) WriteNewNode(node node.Node) (*PagedNode, error) {
+	var pagedNode *PagedNode
+	txErr := p.Atomic(func() error {
+		newPageId, allocateErr := p.AllocatePage()
+		if allocateErr != nil {
+			return allocateErr
+		}
+
+		pagedNode = &PagedNode{newPageId, node}
+		return p.WritePagedNode(pagedNode)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return pagedNode, nil
+}
+
+func (p *Pager) WriteNewRootNode(node node.Node) (*PagedNode, error) {
+	var pagedNode *PagedNode
+	txErr := p.Atomic(func() error {
+		var writeErr error
+		if pagedNode, writeErr = p.WriteNewNode(node); writeErr != nil {
+			return writeErr
+		}
+
+		return p.UpdateRootPage(pagedNode.Page)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return pagedNode, nil
+}
+
+// UpdateRootPage changes the root page, the header is logged when the
+// current transaction commits so the tree is found again after a reopen
+func (p *Pager) UpdateRootPage(pageId uint32) error {
+	return p.Atomic(func() error {
+		p.tx.rootChanged = true
+		p.header.RootPageId = pageId
+		p.header.RootNodeInitialized = true
+		return nil
+	})
+}
+
+func (p *Pager) WritePagedNode(pagedNode *PagedNode) error {
+	return p.WriteNodeToPage(pagedNode.Page, pagedNode.Node)
+}
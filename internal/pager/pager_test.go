@@ -0,0 +1,437 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fileExist(path string) bool {
+	if _, fileErr := os.OpenFile(path, os.O_RDWR, 0644); errors.Is(fileErr, os.ErrNotExist) {
+		return false
+	}
+
+	return true
+}
+
+func TestPagerCreatesNewDatabaseFile(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	assert.False(t, fileExist(dbFileName))
+
+	_, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	assert.True(t, fileExist(dbFileName))
+}
+
+func TestPagerInitedFromExistingFile(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager1, pager1Err := initPagerInNewFile(dbFileName, DefaultOptions())
+	assert.Nil(t, pager1Err)
+	pager1.CloseFile()
+
+	pager2, pager2Err := NewPager(dbFileName)
+	assert.Nil(t, pager2Err)
+
+	var expectedHeader [5]byte
+	copy(expectedHeader[:], "my db")
+	assert.Equal(t, expectedHeader, pager2.header.MagicString)
+}
+
+type reverseComparator struct{}
+
+func (reverseComparator) Compare(a []byte, b []byte) int {
+	return bytes.Compare(b, a)
+}
+
+func (reverseComparator) Name() string {
+	return "reverse"
+}
+
+func TestPagerPersistsComparatorName(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "comparator.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.Comparator = reverseComparator{}
+	pager1, pager1Err := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pager1Err)
+	assert.Equal(t, "reverse", pager1.header.GetComparatorName())
+	assert.Nil(t, pager1.CloseFile())
+
+	_, mismatchErr := NewPager(dbFileName)
+	assert.ErrorIs(t, mismatchErr, ErrComparatorMismatch)
+
+	pager2, pager2Err := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pager2Err)
+	assert.Equal(t, "reverse", pager2.Comparator().Name())
+	assert.Nil(t, pager2.CloseFile())
+}
+
+func TestPagerPageSizeChosenOnCreation(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "page_size.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.PageSize = 16384
+	pager1, pager1Err := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pager1Err)
+	assert.Equal(t, uint32(16384), pager1.PageSize())
+	assert.Equal(t, uint32(16384-PAGE_CHECKSUM_SIZE-node.NODE_HEADER_SIZE), pager1.NodeSize())
+
+	pageData := bytes.Repeat([]byte("1"), 16384)
+	pageId, writeErr := pager1.WriteNewPage(pageData)
+	assert.Nil(t, writeErr)
+	assert.Error(t, pager1.WritePage(pageId, make([]byte, DEFAULT_PAGE_SIZE)))
+	assert.Nil(t, pager1.CloseFile())
+
+	stat, statErr := os.Stat(dbFileName)
+	assert.Nil(t, statErr)
+	assert.Equal(t, int64(2*16384), stat.Size())
+
+	// the page size of the file wins over the options
+	pager2, pager2Err := NewPager(dbFileName)
+	assert.Nil(t, pager2Err)
+	defer pager2.CloseFile()
+	assert.Equal(t, uint32(16384), pager2.PageSize())
+
+	data, readErr := pager2.ReadPage(pageId)
+	assert.Nil(t, readErr)
+	assert.Equal(t, pageData[:16384-PAGE_CHECKSUM_SIZE], data[:16384-PAGE_CHECKSUM_SIZE])
+}
+
+func TestPagerRejectsInvalidPageSize(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "page_size.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	for _, pageSize := range []uint32{2048, 5000, 131072} {
+		options := DefaultOptions()
+		options.PageSize = pageSize
+		_, pagerErr := NewPagerWithOptions(dbFileName, options)
+		assert.ErrorIs(t, pagerErr, ErrInvalidPageSize)
+		assert.False(t, fileExist(dbFileName))
+	}
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	pager.header.PageSizeBytes = 5000
+	assert.Nil(t, pager.header.WriteToFile(pager.file))
+	crash(t, pager)
+
+	_, reopenErr := NewPager(dbFileName)
+	assert.ErrorIs(t, reopenErr, ErrInvalidPageSize)
+}
+
+func TestPagerWritePage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	pageData := pager.NewPageBuffer()
+	err := pager.WritePage(0, pageData)
+	assert.Nil(t, err)
+
+	assert.Nil(t, pager.Checkpoint())
+
+	stat, statErr := pager.file.Stat()
+	assert.Nil(t, statErr)
+
+	assert.Equal(t, int64(2*DEFAULT_PAGE_SIZE), stat.Size())
+}
+
+func TestPagerWriteNewPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	pageData := pager.NewPageBuffer()
+	pageId, err := pager.WriteNewPage(pageData)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), pageId)
+
+	assert.Nil(t, pager.Checkpoint())
+
+	stat, statErr := pager.file.Stat()
+	assert.Nil(t, statErr)
+
+	assert.Equal(t, int64(2*DEFAULT_PAGE_SIZE), stat.Size())
+	assert.Equal(t, uint32(1), pager.header.PageCount)
+}
+
+func TestPagerReadPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	// write data
+	pageData := bytes.Repeat([]byte("1"), DEFAULT_PAGE_SIZE)
+	pageId, err := pager.WriteNewPage(pageData)
+	assert.Nil(t, err)
+
+	// read data
+	data, err := pager.ReadPage(pageId)
+	assert.Nil(t, err)
+
+	assert.Equal(t, pageData, data)
+}
+
+func TestPagerReadingNonExistingPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	data, err := pager.ReadPage(1)
+	assert.Nil(t, data)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to read page data")
+}
+
+func TestPagerWriteReadPagedNode(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "data.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.NoError(t, pagerErr)
+
+	leaf := node.NewEmptyLeafNode(1024)
+	key := []byte{0}
+	data := []byte("data")
+	_, insertErr := leaf.Insert(key, data, node.BytewiseComparator)
+	assert.NoError(t, insertErr)
+
+	pagedNode, writeErr := pager.WriteNewNode(leaf)
+	assert.NoError(t, writeErr)
+
+	readPagedNode, readErr := pager.ReadPagedNode(pagedNode.Page)
+	assert.NoError(t, readErr)
+
+	readLeafNode := readPagedNode.Node.(*node.LeafNode)
+	assert.Equal(t, uint32(1), readLeafNode.GetElementsCount())
+	refKey, refKeyErr := readLeafNode.GetKeyDataRefByIndex(0)
+	assert.NoError(t, refKeyErr)
+	assert.Equal(t, key, refKey.GetKey())
+
+	readData := readLeafNode.GetKeyRefData(refKey)
+	assert.Equal(t, data, readData)
+}
+
+func TestPagerRootPageSurvivesRecovery(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "root.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	assert.False(t, pager.RootNodeInitialized())
+
+	_, writeErr := pager.WriteNewPage(pager.NewPageBuffer())
+	assert.Nil(t, writeErr)
+	rootNode, rootErr := pager.WriteNewRootNode(node.NewEmptyLeafNode(pager.NodeSize()))
+	assert.Nil(t, rootErr)
+	assert.True(t, pager.RootNodeInitialized())
+
+	// a rolled back root change is not applied
+	assert.Nil(t, pager.Begin())
+	assert.Nil(t, pager.UpdateRootPage(0))
+	assert.Nil(t, pager.Rollback())
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.True(t, recovered.RootNodeInitialized())
+	recoveredRoot, readErr := recovered.ReadRootNode()
+	assert.Nil(t, readErr)
+	assert.Equal(t, rootNode.Page, recoveredRoot.Page)
+}
+
+func TestPagerReadViewSeesCommittedState(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "read_view.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	defer pager.CloseFile()
+	writeTestPages(t, pager, 1, 7)
+
+	// the view does not see the transaction in progress
+	assert.Nil(t, pager.Begin())
+	pageData := pager.NewPageBuffer()
+	pageData[0] = 9
+	pageId, writeErr := pager.WriteNewPage(pageData)
+	assert.Nil(t, writeErr)
+	assert.Nil(t, pager.FreePage(0))
+
+	view := pager.BeginRead()
+	assert.ErrorIs(t, view.Begin(), ErrReadOnly)
+
+	// the commit does not wait for the view, the freed page is retained
+	// while the view can read it
+	assert.Nil(t, pager.Commit())
+	viewData, readErr := view.ReadPage(0)
+	assert.Nil(t, readErr)
+	assert.Equal(t, byte(7), viewData[0])
+	assert.Equal(t, uint32(1), view.PageCount())
+
+	allocated, allocateErr := pager.AllocatePage()
+	assert.Nil(t, allocateErr)
+	assert.NotEqual(t, uint32(0), allocated)
+
+	latest := pager.BeginRead()
+	viewData, readErr = latest.ReadPage(pageId)
+	assert.Nil(t, readErr)
+	assert.Equal(t, byte(9), viewData[0])
+	latest.EndRead()
+
+	view.EndRead()
+	allocated, allocateErr = pager.AllocatePage()
+	assert.Nil(t, allocateErr)
+	assert.Equal(t, uint32(0), allocated)
+}
+
+func TestPagerWritersShareFreeList(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "writers.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	defer pager.CloseFile()
+
+	first := pager.NewWriter()
+	second := pager.NewWriter()
+	assert.Nil(t, first.Begin())
+	assert.Nil(t, second.Begin())
+
+	firstPageId, allocateErr := first.WriteNewPage(first.NewPageBuffer())
+	assert.Nil(t, allocateErr)
+
+	// the second writer allocates once the first one committed
+	allocated := make(chan uint32)
+	go func() {
+		pageId, err := second.WriteNewPage(second.NewPageBuffer())
+		assert.Nil(t, err)
+		allocated <- pageId
+	}()
+
+	select {
+	case <-allocated:
+		t.Fatal("allocation did not wait for the free list")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Nil(t, first.UpdateRootPage(firstPageId))
+	assert.Nil(t, first.Commit())
+	secondPageId := <-allocated
+	assert.Equal(t, firstPageId+1, secondPageId)
+	assert.Nil(t, second.Commit())
+
+	// the header keeps the changes of both writers
+	assert.Equal(t, uint32(2), pager.PageCount())
+	assert.Equal(t, firstPageId, pager.RootPageId())
+	assert.True(t, pager.RootNodeInitialized())
+}
+
+func TestPagerLatchPage(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "latches.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	defer pager.CloseFile()
+
+	pager.LatchPage(1, false)
+	pager.LatchPage(1, false)
+	pager.LatchPage(2, true)
+
+	latched := make(chan bool)
+	go func() {
+		pager.LatchPage(1, true)
+		latched <- true
+	}()
+
+	select {
+	case <-latched:
+		t.Fatal("exclusive latch was granted while the page is latched shared")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pager.UnlatchPage(1, false)
+	pager.UnlatchPage(1, false)
+	<-latched
+	pager.UnlatchPage(1, true)
+	pager.UnlatchPage(2, true)
+
+	// latches are dropped once they are released
+	assert.Empty(t, pager.shared.pageLatches.latches)
+}
+
+func TestPagerPersistsCommitTimestamp(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "commit_timestamp.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	assert.Zero(t, pager.CommitTimestamp())
+
+	// a commit without pages stores the timestamp, the header keeps the
+	// largest timestamp of the writers
+	writer := pager.NewWriter()
+	assert.Nil(t, writer.Atomic(func() error {
+		writer.SetCommitTimestamp(5)
+		return nil
+	}))
+	assert.Nil(t, pager.Atomic(func() error {
+		pager.SetCommitTimestamp(3)
+		return nil
+	}))
+	assert.Equal(t, uint64(5), pager.CommitTimestamp())
+
+	// a rolled back timestamp is not stored by the next commit
+	assert.Nil(t, pager.Begin())
+	pager.SetCommitTimestamp(9)
+	assert.Nil(t, pager.Rollback())
+	assert.Nil(t, pager.Atomic(func() error {
+		_, allocateErr := pager.AllocatePage()
+		return allocateErr
+	}))
+	assert.Nil(t, pager.CloseFile())
+
+	pager, pagerErr = NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	defer pager.CloseFile()
+	assert.Equal(t, uint64(5), pager.CommitTimestamp())
+}
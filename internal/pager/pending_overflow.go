@@ -0,0 +1,168 @@
+package pager
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Overflow chains written over several transactions are pending until the
+// value pointing to them is committed. The first page and the number of pages
+// of each pending chain are listed in a page referenced by the header, the
+// chains still listed when the database is opened were left behind by a crash
+// and are freed. The last page of a pending chain is allocated but may not be
+// written yet.
+const PENDING_OVERFLOW_HEADER_SIZE = 4
+
+// first page id u32, page count u32
+type pendingOverflow struct {
+	PageId    uint32
+	PageCount uint32
+}
+
+// pendingOverflowCapacity returns the number of chains the list page holds
+func (p *Pager) pendingOverflowCapacity() uint32 {
+	return (p.usableSize() - PENDING_OVERFLOW_HEADER_SIZE) / 8
+}
+
+func (p *Pager) readPendingOverflows() ([]pendingOverflow, error) {
+	if p.header.PendingOverflowPageId == 0 {
+		return nil, nil
+	}
+
+	pageData, readErr := p.ReadPage(p.header.PendingOverflowPageId)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	count := binary.LittleEndian.Uint32(pageData[0:4])
+	if count > p.pendingOverflowCapacity() {
+		return nil, fmt.Errorf("failed to decode pending overflow chains: invalid count %d", count)
+	}
+
+	chains := make([]pendingOverflow, count)
+	for index := range chains {
+		offset := PENDING_OVERFLOW_HEADER_SIZE + index*8
+		chains[index] = pendingOverflow{
+			binary.LittleEndian.Uint32(pageData[offset:(offset + 4)]),
+			binary.LittleEndian.Uint32(pageData[(offset + 4):(offset + 8)]),
+		}
+	}
+
+	return chains, nil
+}
+
+// writePendingOverflows stores the list of pending chains, its page is
+// allocated for the first chain and freed with the last one
+func (p *Pager) writePendingOverflows(chains []pendingOverflow) error {
+	if uint32(len(chains)) > p.pendingOverflowCapacity() {
+		return ErrTooManyPendingOverflows
+	}
+
+	p.tx.pendingChanged = true
+	if len(chains) == 0 {
+		pageId := p.header.PendingOverflowPageId
+		p.header.PendingOverflowPageId = 0
+		return p.freePage(pageId)
+	}
+
+	if p.header.PendingOverflowPageId == 0 {
+		pageId, allocateErr := p.allocatePage()
+		if allocateErr != nil {
+			return allocateErr
+		}
+		p.header.PendingOverflowPageId = pageId
+	}
+
+	pageData := p.NewPageBuffer()
+	binary.LittleEndian.PutUint32(pageData[0:4], uint32(len(chains)))
+	for index, chain := range chains {
+		offset := PENDING_OVERFLOW_HEADER_SIZE + index*8
+		binary.LittleEndian.PutUint32(pageData[offset:(offset+4)], chain.PageId)
+		binary.LittleEndian.PutUint32(pageData[(offset+4):(offset+8)], chain.PageCount)
+	}
+
+	return p.WritePage(p.header.PendingOverflowPageId, pageData)
+}
+
+// setPendingOverflow lists the chain starting at the page or updates its
+// number of pages
+func (p *Pager) setPendingOverflow(pageId uint32, pageCount uint32) error {
+	chains, readErr := p.readPendingOverflows()
+	if readErr != nil {
+		return readErr
+	}
+
+	for index := range chains {
+		if chains[index].PageId == pageId {
+			chains[index].PageCount = pageCount
+			return p.writePendingOverflows(chains)
+		}
+	}
+
+	return p.writePendingOverflows(append(chains, pendingOverflow{pageId, pageCount}))
+}
+
+// removePendingOverflow stops listing the chain starting at the page
+func (p *Pager) removePendingOverflow(pageId uint32) error {
+	chains, readErr := p.readPendingOverflows()
+	if readErr != nil {
+		return readErr
+	}
+
+	for index := range chains {
+		if chains[index].PageId == pageId {
+			return p.writePendingOverflows(append(chains[:index], chains[(index+1):]...))
+		}
+	}
+
+	return nil
+}
+
+// FreePendingOverflow frees the given pages of a pending chain, starting with
+// its first page, and stops listing the chain
+func (p *Pager) FreePendingOverflow(pageIds []uint32) error {
+	if len(pageIds) == 0 {
+		return nil
+	}
+
+	return p.Atomic(func() error {
+		for _, pageId := range pageIds {
+			if err := p.freePage(pageId); err != nil {
+				return err
+			}
+		}
+
+		return p.removePendingOverflow(pageIds[0])
+	})
+}
+
+// freePendingOverflows frees the pages of every listed chain, the chains are
+// followed through the pages written before the crash
+func (p *Pager) freePendingOverflows() error {
+	chains, readErr := p.readPendingOverflows()
+	if readErr != nil {
+		return readErr
+	}
+
+	for _, chain := range chains {
+		pageId := chain.PageId
+		for index := uint32(0); index < chain.PageCount; index++ {
+			var nextPageId uint32
+			if index+1 < chain.PageCount {
+				pageData, pageErr := p.ReadPage(pageId)
+				if pageErr != nil {
+					return pageErr
+				}
+				nextPageId = binary.LittleEndian.Uint32(pageData[0:4])
+			}
+
+			if err := p.freePage(pageId); err != nil {
+				return err
+			}
+
+			pageId = nextPageId
+		}
+	}
+
+	return p.writePendingOverflows(nil)
+}
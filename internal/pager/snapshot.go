@@ -0,0 +1,99 @@
+package pager
+
+import "sync"
+
+// snapshots tracks the open views. The committed pages a view can read are
+// not overwritten while it is open, the pages freed by later commits are
+// retained until the views which can read them are released.
+type snapshots struct {
+	mu sync.Mutex
+	// number of committed transactions each open view sees
+	open map[*Pager]uint64
+	// pages committed since the last view was taken, no view can read them
+	written map[uint32]bool
+	// pages freed while views were open, by the commit which freed them
+	retained map[uint32]uint64
+}
+
+func newSnapshots() snapshots {
+	return snapshots{
+		open:     make(map[*Pager]uint64),
+		written:  make(map[uint32]bool),
+		retained: make(map[uint32]uint64),
+	}
+}
+
+func (s *snapshots) take(view *Pager, seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.open[view] = seq
+	clear(s.written)
+}
+
+// release drops the view and the retained pages no open view can read
+func (s *snapshots) release(view *Pager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.open, view)
+	if len(s.open) == 0 {
+		clear(s.written)
+		clear(s.retained)
+		return
+	}
+
+	oldest := ^uint64(0)
+	for _, seq := range s.open {
+		oldest = min(oldest, seq)
+	}
+
+	// a page freed by a commit is read by the views taken before it
+	for pageId, freedBy := range s.retained {
+		if freedBy <= oldest {
+			delete(s.retained, pageId)
+		}
+	}
+}
+
+func (s *snapshots) live() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.open) > 0
+}
+
+// canOverwrite reports whether no open view can read the committed page
+func (s *snapshots) canOverwrite(pageId uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.open) == 0 || s.written[pageId]
+}
+
+func (s *snapshots) isRetained(pageId uint32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, retained := s.retained[pageId]
+	return retained
+}
+
+// committed records the pages written and freed by the commit with the
+// given sequence number
+func (s *snapshots) committed(seq uint64, pages map[uint32][]byte, freed []uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.open) == 0 {
+		return
+	}
+
+	for pageId := range pages {
+		s.written[pageId] = true
+	}
+
+	for _, pageId := range freed {
+		s.retained[pageId] = seq
+	}
+}
@@ -0,0 +1,362 @@
+package pager
+
+import (
+	"fmt"
+)
+
+// transaction buffers the pages written until commit, nothing reaches the
+// buffer pool or the file before the transaction is logged
+type transaction struct {
+	pages map[uint32][]byte
+	// header at the start of the transaction, restored on rollback
+	header     DatabaseHeader
+	savepoints []*savepoint
+	// the transaction holds the allocator and owns the free list fields of
+	// the header until it ends
+	allocating bool
+	// the transaction changed the root page
+	rootChanged bool
+	// the transaction changed the list of pending overflow chains
+	pendingChanged bool
+	// pages allocated by the transaction, no view can read them
+	allocated map[uint32]bool
+	// committed pages freed by the transaction, they are returned to the
+	// free list when it commits since views may still read them
+	freed []uint32
+}
+
+// savepoint records the state overwritten by a nested Atomic call so it can
+// be undone without aborting the whole transaction
+type savepoint struct {
+	header DatabaseHeader
+	// previous page data, nil when the page was not written before
+	undo map[uint32][]byte
+	// number of pages freed before the savepoint
+	freed int
+}
+
+func (t *transaction) writePage(pageId uint32, data []byte) {
+	if len(t.savepoints) > 0 {
+		current := t.savepoints[len(t.savepoints)-1]
+		if _, recorded := current.undo[pageId]; !recorded {
+			current.undo[pageId] = t.pages[pageId]
+		}
+	}
+
+	t.pages[pageId] = data
+}
+
+// updateHeaders applies the update to the header of the pager and to the
+// headers of the savepoints, they are restored when a savepoint is undone
+func (t *transaction) updateHeaders(header *DatabaseHeader, update func(header *DatabaseHeader)) {
+	update(header)
+	for _, current := range t.savepoints {
+		update(&current.header)
+	}
+}
+
+// headerToCommit returns the last committed header with the fields owned by
+// the transaction, the other fields may have been changed by other writers
+func (t *transaction) headerToCommit(committed DatabaseHeader, header *DatabaseHeader) DatabaseHeader {
+	if t.allocating {
+		committed.PageCount = header.PageCount
+		committed.FreeListTrunkPageId = header.FreeListTrunkPageId
+		committed.FreePageCount = header.FreePageCount
+	}
+
+	if t.rootChanged {
+		committed.RootPageId = header.RootPageId
+		committed.RootNodeInitialized = header.RootNodeInitialized
+	}
+
+	if t.pendingChanged {
+		committed.PendingOverflowPageId = header.PendingOverflowPageId
+	}
+
+	return committed
+}
+
+func (p *Pager) InTransaction() bool {
+	return p.tx != nil
+}
+
+// Begin starts a transaction on the last committed state, the pages written
+// until Commit or Rollback are only visible through this pager
+func (p *Pager) Begin() error {
+	if p.readOnly {
+		return ErrReadOnly
+	}
+
+	if p.tx != nil {
+		return ErrTransactionInProgress
+	}
+
+	if p.inPlace {
+		p.shared.freeze.RLock()
+	}
+
+	*p.header = p.committedHeader()
+	p.tx = &transaction{
+		pages:     make(map[uint32][]byte),
+		header:    *p.header,
+		allocated: make(map[uint32]bool),
+	}
+	return nil
+}
+
+// CanOverwrite reports whether the transaction can write the page in place.
+// The pages allocated by the transaction can be overwritten, writers
+// returned by NewWriter also overwrite the committed pages no view can
+// read. Other changed pages have to be copied to new pages.
+func (p *Pager) CanOverwrite(pageId uint32) bool {
+	if p.tx == nil {
+		return false
+	}
+
+	if p.tx.allocated[pageId] {
+		return true
+	}
+
+	return p.inPlace && p.shared.snapshots.canOverwrite(pageId)
+}
+
+// Commit makes the pages written in the transaction durable, the
+// transaction is rolled back when it can't be committed
+func (p *Pager) Commit() error {
+	if p.tx == nil {
+		return ErrNoTransaction
+	}
+
+	if err := p.commit(); err != nil {
+		p.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// Rollback discards the pages written in the transaction, including the
+// pages allocated by it
+func (p *Pager) Rollback() error {
+	if p.tx == nil {
+		return ErrNoTransaction
+	}
+
+	p.rollback()
+	return nil
+}
+
+// Atomic runs fn in a transaction. The pages written by fn are committed
+// together when it returns nil and discarded when it returns an error.
+// Nested calls act as savepoints of the outer transaction, an error only
+// undoes the changes made by the nested call.
+func (p *Pager) Atomic(fn func() error) error {
+	if p.tx != nil {
+		return p.atomicNested(fn)
+	}
+
+	if err := p.Begin(); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		p.rollback()
+		return err
+	}
+
+	return p.Commit()
+}
+
+func (p *Pager) atomicNested(fn func() error) error {
+	current := &savepoint{header: *p.header, undo: make(map[uint32][]byte), freed: len(p.tx.freed)}
+	p.tx.savepoints = append(p.tx.savepoints, current)
+
+	fnErr := fn()
+	p.tx.savepoints = p.tx.savepoints[:len(p.tx.savepoints)-1]
+
+	if fnErr != nil {
+		*p.header = current.header
+		p.tx.freed = p.tx.freed[:current.freed]
+		for pageId, data := range current.undo {
+			if data == nil {
+				delete(p.tx.pages, pageId)
+			} else {
+				p.tx.pages[pageId] = data
+			}
+		}
+		return fnErr
+	}
+
+	// the outer savepoint has to be able to undo the nested changes too
+	if len(p.tx.savepoints) > 0 {
+		outer := p.tx.savepoints[len(p.tx.savepoints)-1]
+		for pageId, data := range current.undo {
+			if _, recorded := outer.undo[pageId]; !recorded {
+				outer.undo[pageId] = data
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Pager) rollback() {
+	*p.header = p.tx.header
+	p.endTransaction()
+}
+
+// endTransaction releases the allocator when the transaction holds it
+func (p *Pager) endTransaction() {
+	p.commitTimestamp = 0
+	if p.tx.allocating {
+		p.shared.allocator.Unlock()
+	}
+
+	if p.inPlace {
+		p.shared.freeze.RUnlock()
+	}
+
+	p.tx = nil
+}
+
+func (p *Pager) commit() error {
+	if len(p.tx.pages) == 0 && len(p.tx.freed) == 0 && *p.header == p.tx.header && p.commitTimestamp == 0 {
+		p.endTransaction()
+		return nil
+	}
+
+	// the allocator is taken before the commit lock like in the transaction
+	if len(p.tx.freed) > 0 {
+		p.lockAllocator()
+	}
+
+	p.shared.commitLock.Lock()
+	defer p.shared.commitLock.Unlock()
+
+	// views are taken under the commit lock, they can't begin while the
+	// freed pages are returned to the free list
+	retain := p.shared.snapshots.live()
+	for _, pageId := range p.tx.freed {
+		if err := p.releasePage(pageId, retain); err != nil {
+			return err
+		}
+	}
+
+	header := p.tx.headerToCommit(p.shared.committed, p.header)
+	header.CommitTimestamp = max(header.CommitTimestamp, p.commitTimestamp)
+	headerData, encodeErr := header.encode()
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	if err := p.wal.appendTransaction(p.tx.pages, headerData); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// readers see either none or all of the pages of the transaction
+	p.shared.latch.Lock()
+	defer p.shared.latch.Unlock()
+
+	// the transaction is committed once it is logged, nothing from here on
+	// can fail. The pages are written to the file on eviction or checkpoint.
+	for pageId, data := range p.tx.pages {
+		p.pool.Put(pageId, data)
+	}
+	p.shared.committed = header
+	p.shared.seq += 1
+	p.shared.snapshots.committed(p.shared.seq, p.tx.pages, p.tx.freed)
+	*p.header = header
+	p.endTransaction()
+
+	// a failed checkpoint leaves the transaction in the log, it is retried by
+	// the next commit and by Close
+	if p.wal.needsCheckpoint(p.walCheckpointSize) {
+		p.checkpoint()
+	}
+
+	return nil
+}
+
+// Checkpoint writes the committed pages and the header into the database
+// file and empties the log
+func (p *Pager) Checkpoint() error {
+	if p.tx != nil {
+		return fmt.Errorf("failed to checkpoint: %w", ErrTransactionInProgress)
+	}
+
+	p.shared.commitLock.Lock()
+	defer p.shared.commitLock.Unlock()
+	p.shared.latch.Lock()
+	defer p.shared.latch.Unlock()
+
+	return p.checkpoint()
+}
+
+func (p *Pager) checkpoint() error {
+	if err := p.pool.Flush(); err != nil {
+		return err
+	}
+
+	header := p.shared.committed
+	if err := header.WriteToFile(p.file); err != nil {
+		return err
+	}
+
+	return p.wal.truncate()
+}
+
+// recover replays the committed transactions of the log into the database
+// file, incomplete transactions are discarded
+func (p *Pager) recover() error {
+	// the page size and the layout of the file come from the header, a torn
+	// header is restored from the wal before the pages are replayed
+	if p.header == nil {
+		if err := p.wal.replay(p.replayHeader); err != nil {
+			return fmt.Errorf("failed to recover from the wal: %w", err)
+		}
+
+		if p.header == nil {
+			return ErrCorruptHeader
+		}
+
+		if err := p.header.validate(); err != nil {
+			return err
+		}
+		p.pageSize = p.header.PageSizeBytes
+		p.formatVersion = p.header.FormatVersion
+	}
+
+	replayErr := p.wal.replay(func(record *walRecord) error {
+		switch record.Type {
+		case walPageRecord:
+			return p.writePageToFile(record.PageId, record.Payload)
+		case walHeaderRecord:
+			return p.replayHeader(record)
+		default:
+			return fmt.Errorf("unexpected wal record type: %d", record.Type)
+		}
+	})
+	if replayErr != nil {
+		return fmt.Errorf("failed to recover from the wal: %w", replayErr)
+	}
+
+	if err := p.header.WriteToFile(p.file); err != nil {
+		return err
+	}
+
+	return p.wal.truncate()
+}
+
+func (p *Pager) replayHeader(record *walRecord) error {
+	if record.Type != walHeaderRecord {
+		return nil
+	}
+
+	header, headerErr := decodeDatabaseHeader(record.Payload)
+	if headerErr != nil {
+		return headerErr
+	}
+
+	p.header = header
+	return nil
+}
@@ -0,0 +1,80 @@
+package pager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const UPGRADE_FILE_SUFFIX = ".upgrade"
+
+// upgradeFile rewrites a file of an older format version in the current
+// format. The pages are copied into a new file which then replaces the old
+// one, a crash leaves either the old or the upgraded file behind.
+func (p *Pager) upgradeFile() error {
+	filePath := p.file.Name()
+	upgradePath := filePath + UPGRADE_FILE_SUFFIX
+	upgraded, createErr := os.OpenFile(upgradePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if createErr != nil {
+		return fmt.Errorf("failed to upgrade database file: %v", createErr)
+	}
+
+	header := *p.header
+	header.FormatVersion = FILE_FORMAT_VERSION
+	if err := p.copyPagesInto(upgraded, &header); err != nil {
+		upgraded.Close()
+		os.Remove(upgradePath)
+		return err
+	}
+
+	if err := os.Rename(upgradePath, filePath); err != nil {
+		upgraded.Close()
+		os.Remove(upgradePath)
+		return fmt.Errorf("failed to replace database file: %v", err)
+	}
+
+	if err := syncDir(filepath.Dir(filePath)); err != nil {
+		upgraded.Close()
+		return err
+	}
+
+	p.file.Close()
+	p.file = upgraded
+	p.header = &header
+	p.formatVersion = header.FormatVersion
+
+	return nil
+}
+
+// copyPagesInto writes the pages of the file at the offsets of the header
+// format followed by the header itself
+func (p *Pager) copyPagesInto(file *os.File, header *DatabaseHeader) error {
+	// pages are copied as they are, including pages which were allocated but
+	// never written
+	pagesSize := int64(p.header.PageCount) * int64(p.pageSize)
+	pages := io.NewSectionReader(p.file, p.PageFileOffset(0), pagesSize)
+	if _, err := io.Copy(io.NewOffsetWriter(file, int64(p.pageSize)), pages); err != nil {
+		return fmt.Errorf("failed to copy pages into the upgraded file: %v", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to flush the upgraded file: %v", err)
+	}
+
+	return header.WriteToFile(file)
+}
+
+func syncDir(path string) error {
+	dir, openErr := os.Open(path)
+	if openErr != nil {
+		return fmt.Errorf("failed to open directory: %v", openErr)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("failed to flush directory: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package pager
+
+import (
+	"bricker-db/internal/btree/node"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLegacyFile rewrites the closed database file in the layout of the
+// first release, a header without a checksum, a format version or a
+// comparator name followed directly by the pages
+func writeLegacyFile(t *testing.T, dbFileName string, pageSize uint32) {
+	file, fileErr := os.OpenFile(dbFileName, os.O_RDWR, 0644)
+	assert.Nil(t, fileErr)
+	header, headerErr := ReadFromFile(file)
+	assert.Nil(t, headerErr)
+
+	legacy := make([]byte, DATABASE_HEADER_SIZE+int(header.PageCount*pageSize))
+	_, readErr := file.ReadAt(legacy[DATABASE_HEADER_SIZE:], int64(pageSize))
+	assert.Nil(t, readErr)
+	assert.Nil(t, file.Close())
+
+	// magic string, page size, page count, root page id, root initialized
+	copy(legacy[0:5], MAGIC_STRING)
+	binary.LittleEndian.PutUint32(legacy[5:9], pageSize)
+	binary.LittleEndian.PutUint32(legacy[9:13], header.PageCount)
+	binary.LittleEndian.PutUint32(legacy[13:17], header.RootPageId)
+	if header.RootNodeInitialized {
+		legacy[17] = 1
+	}
+	assert.Nil(t, os.WriteFile(dbFileName, legacy, 0644))
+}
+
+func TestPagerUpgradesLegacyFileLayout(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "upgrade.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.PageSize = 8192
+	pager, pagerErr := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pagerErr)
+	for i := 0; i < 5; i++ {
+		pageData := pager.NewPageBuffer()
+		pageData[0] = byte(i + 1)
+		_, writeErr := pager.WriteNewPage(pageData)
+		assert.Nil(t, writeErr)
+	}
+	assert.Nil(t, pager.CloseFile())
+
+	writeLegacyFile(t, dbFileName, 8192)
+	stat, statErr := os.Stat(dbFileName)
+	assert.Nil(t, statErr)
+	assert.Equal(t, int64(DATABASE_HEADER_SIZE+5*8192), stat.Size())
+
+	upgraded, upgradedErr := NewPager(dbFileName)
+	assert.Nil(t, upgradedErr)
+	assert.Equal(t, FILE_FORMAT_VERSION, upgraded.header.FormatVersion)
+	assert.Equal(t, uint32(8192), upgraded.PageSize())
+	assert.Equal(t, uint32(5), upgraded.PageCount())
+	assert.Equal(t, node.BytewiseComparator.Name(), upgraded.header.GetComparatorName())
+	assert.False(t, fileExist(dbFileName+UPGRADE_FILE_SUFFIX))
+
+	for pageId := uint32(0); pageId < 5; pageId++ {
+		data, readErr := upgraded.ReadPage(pageId)
+		assert.Nil(t, readErr)
+		assert.Equal(t, byte(pageId+1), data[0])
+	}
+
+	// pages are allocated after the copied ones
+	pageId, allocateErr := upgraded.AllocatePage()
+	assert.Nil(t, allocateErr)
+	assert.Equal(t, uint32(5), pageId)
+	assert.Nil(t, upgraded.CloseFile())
+
+	stat, statErr = os.Stat(dbFileName)
+	assert.Nil(t, statErr)
+	assert.Equal(t, int64(6*8192), stat.Size())
+}
+
+func TestPagerRejectsNewerFileFormat(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "upgrade.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	pager.header.FormatVersion = FILE_FORMAT_VERSION + 1
+	assert.Nil(t, pager.header.WriteToFile(pager.file))
+	crash(t, pager)
+
+	_, reopenErr := NewPager(dbFileName)
+	assert.ErrorIs(t, reopenErr, ErrUnsupportedFileFormat)
+}
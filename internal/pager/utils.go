@@ -0,0 +1,32 @@
+package pager
+
+import "fmt"
+
+func validatePageSize(pageSize uint32) error {
+	if pageSize < MIN_PAGE_SIZE || pageSize > MAX_PAGE_SIZE || pageSize&(pageSize-1) != 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidPageSize, pageSize)
+	}
+
+	return nil
+}
+
+// PageFileOffset returns the offset of the page in the database file, 64 bit
+// so files with large pages can grow past 4GB
+func (p *Pager) PageFileOffset(pageId uint32) int64 {
+	if p.formatVersion == LegacyFileFormatVersion {
+		return DATABASE_HEADER_SIZE + int64(pageId)*int64(p.pageSize)
+	}
+
+	// page 0 of the file holds the header, data pages are aligned to the
+	// page size
+	return (int64(pageId) + 1) * int64(p.pageSize)
+}
+
+func (p *Pager) NewPageBuffer() []byte {
+	return make([]byte, p.pageSize)
+}
+
+// usableSize returns the number of bytes of a page before its checksum
+func (p *Pager) usableSize() uint32 {
+	return p.pageSize - PAGE_CHECKSUM_SIZE
+}
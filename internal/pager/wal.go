@@ -0,0 +1,303 @@
+package pager
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+const WAL_FILE_SUFFIX = "-wal"
+
+// lsn u64, type u8, page id u32, payload length u32
+const WAL_RECORD_HEADER_SIZE = 17
+const WAL_RECORD_CHECKSUM_SIZE = 4
+
+// number of logged pages after which the log is checkpointed into the
+// database file
+const DEFAULT_WAL_CHECKPOINT_SIZE = 1000
+
+type walRecordType uint8
+
+const (
+	walPageRecord walRecordType = iota + 1
+	walHeaderRecord
+	walCommitRecord
+)
+
+var errInvalidWalRecord = errors.New("invalid wal record")
+
+type walRecord struct {
+	Lsn     uint64
+	Type    walRecordType
+	PageId  uint32
+	Payload []byte
+}
+
+func (r *walRecord) encode() []byte {
+	buf := make([]byte, WAL_RECORD_HEADER_SIZE+len(r.Payload)+WAL_RECORD_CHECKSUM_SIZE)
+	binary.LittleEndian.PutUint64(buf[0:8], r.Lsn)
+	buf[8] = byte(r.Type)
+	binary.LittleEndian.PutUint32(buf[9:13], r.PageId)
+	binary.LittleEndian.PutUint32(buf[13:17], uint32(len(r.Payload)))
+	copy(buf[WAL_RECORD_HEADER_SIZE:], r.Payload)
+
+	checksumOffset := WAL_RECORD_HEADER_SIZE + len(r.Payload)
+	binary.LittleEndian.PutUint32(buf[checksumOffset:], crc32.Checksum(buf[:checksumOffset], crc32cTable))
+
+	return buf
+}
+
+// readWalRecord reads the record at the given offset, a torn or corrupted
+// record is reported as errInvalidWalRecord
+func readWalRecord(file *os.File, offset int64) (*walRecord, int64, error) {
+	header := make([]byte, WAL_RECORD_HEADER_SIZE)
+	if _, err := file.ReadAt(header, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, errInvalidWalRecord
+		}
+		return nil, 0, fmt.Errorf("failed to read wal record: %v", err)
+	}
+
+	payloadSize := binary.LittleEndian.Uint32(header[13:17])
+	if payloadSize > MAX_PAGE_SIZE {
+		return nil, 0, errInvalidWalRecord
+	}
+
+	rest := make([]byte, int(payloadSize)+WAL_RECORD_CHECKSUM_SIZE)
+	if _, err := file.ReadAt(rest, offset+WAL_RECORD_HEADER_SIZE); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, errInvalidWalRecord
+		}
+		return nil, 0, fmt.Errorf("failed to read wal record: %v", err)
+	}
+
+	checksum := crc32.Checksum(header, crc32cTable)
+	checksum = crc32.Update(checksum, crc32cTable, rest[:payloadSize])
+	if checksum != binary.LittleEndian.Uint32(rest[payloadSize:]) {
+		return nil, 0, errInvalidWalRecord
+	}
+
+	record := &walRecord{
+		Lsn:     binary.LittleEndian.Uint64(header[0:8]),
+		Type:    walRecordType(header[8]),
+		PageId:  binary.LittleEndian.Uint32(header[9:13]),
+		Payload: rest[:payloadSize],
+	}
+
+	return record, offset + int64(len(header)+len(rest)), nil
+}
+
+// Wal is an append only log of page images. A transaction is made of page
+// and header records followed by a commit record, only transactions whose
+// commit record reached the disk are replayed during recovery. The log is
+// appended by the writer and synced by the readers evicting pages.
+type Wal struct {
+	mu       sync.Mutex
+	file     *os.File
+	offset   int64
+	nextLsn  uint64
+	syncMode SyncMode
+	// records appended since the log was last synced
+	unsynced bool
+	// pages logged since the last checkpoint
+	loggedPages int
+	// set when a failed append could not be removed from the log, the log
+	// can't be appended to anymore
+	err error
+	// flushes the file, tests replace it to make the sync fail
+	syncFile func() error
+}
+
+// SyncMode controls when committed transactions are flushed to the disk
+type SyncMode int
+
+const (
+	// SyncFull flushes the wal on every commit, committed transactions
+	// survive a crash
+	SyncFull SyncMode = iota
+	// SyncOff leaves flushing the wal to the operating system until a page
+	// is written back or the wal is checkpointed, a crash can lose the last
+	// committed transactions but never leaves one half applied
+	SyncOff
+)
+
+func openWal(filePath string, syncMode SyncMode) (*Wal, error) {
+	file, fileErr := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE, 0644)
+	if fileErr != nil {
+		return nil, fmt.Errorf("failed to open wal file: %v", fileErr)
+	}
+
+	return &Wal{file: file, nextLsn: 1, syncMode: syncMode, syncFile: file.Sync}, nil
+}
+
+// appendTransaction logs the pages and the header of a transaction, with
+// SyncFull the log is synced and the transaction is durable once it returns.
+// A failed transaction is removed from the log so it is never replayed.
+func (w *Wal) appendTransaction(pages map[uint32][]byte, header []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	pageIds := make([]uint32, 0, len(pages))
+	for pageId := range pages {
+		pageIds = append(pageIds, pageId)
+	}
+	sort.Slice(pageIds, func(i, j int) bool { return pageIds[i] < pageIds[j] })
+
+	var buf []byte
+	lsn := w.nextLsn
+	for _, pageId := range pageIds {
+		record := &walRecord{Lsn: lsn, Type: walPageRecord, PageId: pageId, Payload: pages[pageId]}
+		buf = append(buf, record.encode()...)
+		lsn += 1
+	}
+
+	headerRecord := &walRecord{Lsn: lsn, Type: walHeaderRecord, Payload: header}
+	buf = append(buf, headerRecord.encode()...)
+	lsn += 1
+
+	commitRecord := &walRecord{Lsn: lsn, Type: walCommitRecord}
+	buf = append(buf, commitRecord.encode()...)
+	lsn += 1
+
+	// the records left behind by a failed append are older than the records
+	// of the next transaction, the replay stops at them
+	w.nextLsn = lsn
+
+	if _, err := w.file.WriteAt(buf, w.offset); err != nil {
+		w.discardFrom(w.offset)
+		return fmt.Errorf("failed to append transaction to the wal: %v", err)
+	}
+
+	w.unsynced = true
+	if w.syncMode == SyncFull {
+		if err := w.syncLocked(); err != nil {
+			w.discardFrom(w.offset)
+			return err
+		}
+	}
+
+	w.offset += int64(len(buf))
+	w.loggedPages += len(pageIds)
+
+	return nil
+}
+
+// discardFrom removes the records from the offset on, the log becomes
+// unusable when they can't be removed
+func (w *Wal) discardFrom(offset int64) {
+	if err := w.file.Truncate(offset); err != nil {
+		w.err = fmt.Errorf("failed to discard a failed transaction from the wal: %v", err)
+		return
+	}
+
+	if err := w.syncFile(); err != nil {
+		w.err = fmt.Errorf("failed to discard a failed transaction from the wal: %v", err)
+		return
+	}
+
+	w.unsynced = false
+}
+
+// replay calls apply for every record of the committed transactions in log
+// order, records after the last commit record are discarded
+func (w *Wal) replay(apply func(record *walRecord) error) error {
+	var pending []*walRecord
+	var offset int64
+	var lastLsn uint64
+
+	for {
+		record, nextOffset, readErr := readWalRecord(w.file, offset)
+		if errors.Is(readErr, errInvalidWalRecord) {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+
+		// a stale record from an earlier log ends the valid part of the log
+		if record.Lsn <= lastLsn {
+			break
+		}
+
+		lastLsn = record.Lsn
+		offset = nextOffset
+
+		if record.Type != walCommitRecord {
+			pending = append(pending, record)
+			continue
+		}
+
+		for _, pendingRecord := range pending {
+			if err := apply(pendingRecord); err != nil {
+				return err
+			}
+		}
+		pending = nil
+	}
+
+	return nil
+}
+
+// sync flushes the records appended since the last sync
+func (w *Wal) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.syncLocked()
+}
+
+func (w *Wal) syncLocked() error {
+	if !w.unsynced {
+		return nil
+	}
+
+	if err := w.syncFile(); err != nil {
+		return fmt.Errorf("failed to sync the wal: %v", err)
+	}
+
+	w.unsynced = false
+	return nil
+}
+
+// truncate empties the log, it must only be called once the logged pages
+// are synced into the database file
+func (w *Wal) truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate the wal: %v", err)
+	}
+
+	if err := w.syncFile(); err != nil {
+		return fmt.Errorf("failed to sync the wal: %v", err)
+	}
+
+	w.offset = 0
+	w.unsynced = false
+	w.loggedPages = 0
+
+	return nil
+}
+
+// needsCheckpoint reports whether enough pages were logged since the last
+// checkpoint
+func (w *Wal) needsCheckpoint(checkpointSize int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.loggedPages >= checkpointSize
+}
+
+func (w *Wal) close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,281 @@
+package pager
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// crash closes the files without checkpointing the wal
+func crash(t *testing.T, pager *Pager) {
+	assert.Nil(t, pager.wal.close())
+	assert.Nil(t, pager.file.Close())
+}
+
+func writeTestPages(t *testing.T, pager *Pager, count int, value byte) {
+	err := pager.Atomic(func() error {
+		for i := 0; i < count; i++ {
+			pageData := pager.NewPageBuffer()
+			pageData[0] = value
+			if _, err := pager.WriteNewPage(pageData); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestWalRecoversCommittedTransactions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_recover.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	writeTestPages(t, pager, 3, 7)
+	assert.Nil(t, pager.UpdateRootPage(2))
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.Equal(t, uint32(3), recovered.PageCount())
+	assert.Equal(t, uint32(2), recovered.header.RootPageId)
+	for i := uint32(0); i < 3; i++ {
+		pageData, err := recovered.ReadPage(i)
+		assert.Nil(t, err)
+		assert.Equal(t, byte(7), pageData[0])
+	}
+
+	walStat, statErr := recovered.wal.file.Stat()
+	assert.Nil(t, statErr)
+	assert.Equal(t, int64(0), walStat.Size())
+}
+
+func TestWalDiscardsIncompleteTransactions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_incomplete.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	writeTestPages(t, pager, 1, 7)
+
+	// page record without a commit record
+	pageData := pager.NewPageBuffer()
+	pageData[0] = 9
+	record := &walRecord{Lsn: pager.wal.nextLsn, Type: walPageRecord, PageId: 0, Payload: pageData}
+	_, writeErr := pager.wal.file.WriteAt(record.encode(), pager.wal.offset)
+	assert.Nil(t, writeErr)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	recoveredData, readErr := recovered.ReadPage(0)
+	assert.Nil(t, readErr)
+	assert.Equal(t, byte(7), recoveredData[0])
+}
+
+func TestWalDiscardsTransactionWithFailedSync(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_failed_sync.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	writeTestPages(t, pager, 1, 7)
+
+	// the sync of the first transaction fails after its records were written
+	syncFailure := errors.New("sync failure")
+	syncFile := pager.wal.syncFile
+	failures := 1
+	pager.wal.syncFile = func() error {
+		if failures > 0 {
+			failures -= 1
+			return syncFailure
+		}
+		return syncFile()
+	}
+
+	failedErr := pager.Atomic(func() error {
+		for i := 0; i < 5; i++ {
+			pageData := pager.NewPageBuffer()
+			pageData[0] = 8
+			if _, err := pager.WriteNewPage(pageData); err != nil {
+				return err
+			}
+		}
+
+		pageData := pager.NewPageBuffer()
+		pageData[0] = 8
+		return pager.WritePage(0, pageData)
+	})
+	assert.ErrorContains(t, failedErr, syncFailure.Error())
+
+	// a shorter transaction is logged where the failed one was
+	pageData := pager.NewPageBuffer()
+	pageData[0] = 9
+	_, writeErr := pager.WriteNewPage(pageData)
+	assert.Nil(t, writeErr)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.Equal(t, uint32(2), recovered.PageCount())
+	for pageId, value := range []byte{7, 9} {
+		recoveredData, readErr := recovered.ReadPage(uint32(pageId))
+		assert.Nil(t, readErr)
+		assert.Equal(t, value, recoveredData[0])
+	}
+}
+
+func TestWalUnusableWhenFailedTransactionStays(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_failed_sync.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	// the failed transaction can't be removed from the log either
+	syncFailure := errors.New("sync failure")
+	pager.wal.syncFile = func() error {
+		return syncFailure
+	}
+
+	pageData := pager.NewPageBuffer()
+	_, writeErr := pager.WriteNewPage(pageData)
+	assert.ErrorContains(t, writeErr, syncFailure.Error())
+	assert.NotNil(t, pager.wal.err)
+
+	_, writeErr = pager.WriteNewPage(pageData)
+	assert.ErrorContains(t, writeErr, pager.wal.err.Error())
+	crash(t, pager)
+}
+
+func TestWalIgnoresTornRecords(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_torn.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+
+	writeTestPages(t, pager, 1, 7)
+	writeTestPages(t, pager, 1, 8)
+
+	// corrupt the payload of the second transaction
+	offset := pager.wal.offset - WAL_RECORD_CHECKSUM_SIZE - 1
+	_, writeErr := pager.wal.file.WriteAt([]byte{0xff, 0xff, 0xff}, offset-DEFAULT_PAGE_SIZE)
+	assert.Nil(t, writeErr)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+
+	assert.Equal(t, uint32(1), recovered.PageCount())
+}
+
+func TestAtomicRollsBackOnError(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_rollback.db"
+	defer os.Remove(dbFileName)
+
+	pager, pagerErr := NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	defer pager.CloseFile()
+
+	writeTestPages(t, pager, 1, 7)
+
+	failure := errors.New("failure")
+	err := pager.Atomic(func() error {
+		pageData := pager.NewPageBuffer()
+		pageData[0] = 9
+		if err := pager.WritePage(0, pageData); err != nil {
+			return err
+		}
+
+		if _, err := pager.WriteNewPage(pageData); err != nil {
+			return err
+		}
+
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	assert.Equal(t, uint32(1), pager.PageCount())
+	pageData, readErr := pager.ReadPage(0)
+	assert.Nil(t, readErr)
+	assert.Equal(t, byte(7), pageData[0])
+}
+
+func TestWalCheckpoint(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_checkpoint.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	pager, pagerErr := NewPagerWithOptions(dbFileName, &Options{BufferPoolSize: 4, WalCheckpointSize: 2})
+	assert.Nil(t, pagerErr)
+
+	writeTestPages(t, pager, 3, 7)
+
+	// the log grew past the checkpoint size and was written into the file
+	assert.Equal(t, int64(0), pager.wal.offset)
+	header, headerErr := ReadFromFile(pager.file)
+	assert.Nil(t, headerErr)
+	assert.Equal(t, uint32(3), header.PageCount)
+
+	assert.Nil(t, pager.CloseFile())
+	_, statErr := os.Stat(dbFileName + WAL_FILE_SUFFIX)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestWalSyncOffSyncsBeforeWriteBack(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "wal_sync.db"
+	defer os.Remove(dbFileName)
+	defer os.Remove(dbFileName + WAL_FILE_SUFFIX)
+
+	created, createdErr := NewPager(dbFileName)
+	assert.Nil(t, createdErr)
+	writeTestPages(t, created, 1, 7)
+	assert.Nil(t, created.CloseFile())
+
+	options := DefaultOptions()
+	options.SyncMode = SyncOff
+	options.BufferPoolSize = 1
+	pager, pagerErr := NewPagerWithOptions(dbFileName, options)
+	assert.Nil(t, pagerErr)
+
+	writeTestPages(t, pager, 1, 8)
+	assert.True(t, pager.wal.unsynced)
+
+	// the miss evicts the committed page, it is written back after syncing
+	// the wal
+	_, readErr := pager.ReadPage(0)
+	assert.Nil(t, readErr)
+	assert.False(t, pager.wal.unsynced)
+	crash(t, pager)
+
+	recovered, recoveredErr := NewPager(dbFileName)
+	assert.Nil(t, recoveredErr)
+	defer recovered.CloseFile()
+	assert.Equal(t, uint32(2), recovered.PageCount())
+}
@@ -14,8 +14,9 @@ func NewFixedSizeSliceWriter(buf []byte) *FixedSizeSliceWriter {
 }
 
 func (f *FixedSizeSliceWriter) Write(p []byte) (n int, err error) {
-	if len(p) > cap(f.buf) {
-		return 0, fmt.Errorf("buffer is to small: buffer capacity: %d, len of data being written: %d", cap(f.buf), len(p))
+	remaining := len(f.buf) - f.offset
+	if len(p) > remaining {
+		return 0, fmt.Errorf("buffer is to small: buffer capacity: %d, len of data being written: %d", remaining, len(p))
 	}
 
 	bytesWritten := copy(f.buf[f.offset:], p)
@@ -54,3 +54,14 @@ func TestFixedSizeSliceWriterErrorsWhenTooMuchDataIsWritten(t *testing.T) {
 	assert.NotNil(t, writerErr)
 	assert.Equal(t, "buffer is to small: buffer capacity: 1, len of data being written: 3", writerErr.Error())
 }
+
+func TestFixedSizeSliceWriterErrorsWhenAppendedDataDoesNotFit(t *testing.T) {
+	buf := make([]byte, 4)
+	writer := NewFixedSizeSliceWriter(buf)
+	_, writerErr := writer.Write([]byte("asd"))
+	assert.NoError(t, writerErr)
+
+	bytesWritten, writerErr := writer.Write([]byte("asd"))
+	assert.Zero(t, bytesWritten)
+	assert.Equal(t, "buffer is to small: buffer capacity: 1, len of data being written: 3", writerErr.Error())
+}
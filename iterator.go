@@ -0,0 +1,361 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/mvcc"
+	"bytes"
+	"slices"
+	"sort"
+)
+
+// Range limits the keys visited by an iterator, nil bounds are unbounded
+type Range = operations.Range
+
+// Bound is the start or the end of a range
+type Bound = operations.Bound
+
+// Iterator walks the keys a transaction sees in ascending or descending
+// order of the comparator. It reads the versions of the snapshot of the
+// transaction together with the writes the transaction made before the
+// iterator was created, deleted keys are skipped.
+type Iterator struct {
+	tx *Tx
+	// the iterator began the transaction and ends it on Close
+	ownsTx   bool
+	keyRange *Range
+	cursor   *operations.Cursor
+	// keys written by the transaction within the range, in the order of the
+	// comparator, and their versions
+	written [][]byte
+	writes  map[string]mvcc.Version
+	// position of the next written key in the direction of the iteration
+	writtenIndex int
+	forward      bool
+	key          []byte
+	version      mvcc.Version
+	valid        bool
+	err          error
+}
+
+// NewIterator returns an iterator over the keys of the range in a read only
+// transaction which is ended by Close, a nil range iterates all the keys
+func (db *DB) NewIterator(keyRange *Range) (*Iterator, error) {
+	tx, beginErr := db.Begin(false)
+	if beginErr != nil {
+		return nil, beginErr
+	}
+
+	iterator, iteratorErr := tx.NewIterator(keyRange)
+	if iteratorErr != nil {
+		tx.Rollback()
+		return nil, iteratorErr
+	}
+
+	iterator.ownsTx = true
+	return iterator, nil
+}
+
+// NewIterator returns an iterator over the keys of the range the
+// transaction sees, a nil range iterates all the keys. The iterator can't be
+// used once the transaction ended.
+func (tx *Tx) NewIterator(keyRange *Range) (*Iterator, error) {
+	if tx.closed {
+		return nil, ErrTxClosed
+	}
+
+	// the bounds are copied, the caller may reuse its slices
+	keyRange = cloneRange(keyRange)
+	iterator := &Iterator{
+		tx:       tx,
+		keyRange: keyRange,
+		cursor:   operations.NewCursor(tx.pager, keyRange),
+		writes:   make(map[string]mvcc.Version),
+	}
+
+	comparator := tx.pager.Comparator()
+	for key, version := range tx.writes {
+		if keyRange.Contains([]byte(key), comparator) {
+			iterator.written = append(iterator.written, []byte(key))
+			iterator.writes[key] = version
+		}
+	}
+	slices.SortFunc(iterator.written, comparator.Compare)
+
+	return iterator, nil
+}
+
+// First positions the iterator at the lowest key of the range
+func (it *Iterator) First() bool {
+	if !it.open() {
+		return false
+	}
+
+	it.cursor.First()
+	it.writtenIndex = 0
+	it.forward = true
+	return it.settle()
+}
+
+// Last positions the iterator at the highest key of the range
+func (it *Iterator) Last() bool {
+	if !it.open() {
+		return false
+	}
+
+	it.cursor.Last()
+	it.writtenIndex = len(it.written) - 1
+	it.forward = false
+	return it.settle()
+}
+
+// Seek positions the iterator at the lowest key of the range that is greater
+// or equal to the given key
+func (it *Iterator) Seek(key []byte) bool {
+	if !it.open() {
+		return false
+	}
+
+	return it.seekForward(key, true)
+}
+
+// SeekForPrev positions the iterator at the highest key of the range that is
+// lower or equal to the given key
+func (it *Iterator) SeekForPrev(key []byte) bool {
+	if !it.open() {
+		return false
+	}
+
+	return it.seekBackward(key, true)
+}
+
+// Next moves the iterator to the following key of the range
+func (it *Iterator) Next() bool {
+	if !it.Valid() || !it.open() {
+		return false
+	}
+
+	if !it.forward {
+		return it.seekForward(it.key, false)
+	}
+
+	return it.settle()
+}
+
+// Prev moves the iterator to the preceding key of the range
+func (it *Iterator) Prev() bool {
+	if !it.Valid() || !it.open() {
+		return false
+	}
+
+	if it.forward {
+		return it.seekBackward(it.key, false)
+	}
+
+	return it.settle()
+}
+
+func (it *Iterator) Valid() bool {
+	return it.valid && it.err == nil
+}
+
+// Key returns a copy of the current key
+func (it *Iterator) Key() []byte {
+	if !it.Valid() {
+		return nil
+	}
+
+	return bytes.Clone(it.key)
+}
+
+// Value returns a copy of the value of the current key, a blob is read from
+// its overflow pages. A failure to read the value is reported by Err.
+func (it *Iterator) Value() []byte {
+	if !it.Valid() || !it.open() {
+		return nil
+	}
+
+	data, readErr := readVersion(it.tx.pager, it.version)
+	if readErr != nil {
+		it.fail(readErr)
+		return nil
+	}
+
+	if !it.version.Blob {
+		return bytes.Clone(data)
+	}
+
+	return data
+}
+
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close ends the transaction of an iterator returned by DB.NewIterator, the
+// transaction of an iterator returned by Tx.NewIterator is left open
+func (it *Iterator) Close() error {
+	it.valid = false
+	if it.ownsTx {
+		return it.tx.Commit()
+	}
+
+	return nil
+}
+
+func (it *Iterator) open() bool {
+	if it.tx.closed {
+		return it.fail(ErrTxClosed)
+	}
+
+	return true
+}
+
+func (it *Iterator) fail(err error) bool {
+	it.valid = false
+	it.err = err
+	return false
+}
+
+// seekForward positions the sources at the first keys above the key, or at
+// the key when it is inclusive, and iterates forward from there
+func (it *Iterator) seekForward(key []byte, inclusive bool) bool {
+	comparator := it.tx.pager.Comparator()
+	if it.cursor.Seek(key) && !inclusive && comparator.Compare(it.cursor.Key(), key) == 0 {
+		it.cursor.Next()
+	}
+
+	it.writtenIndex = sort.Search(len(it.written), func(index int) bool {
+		comparison := comparator.Compare(it.written[index], key)
+		return comparison > 0 || (inclusive && comparison == 0)
+	})
+	it.forward = true
+	return it.settle()
+}
+
+// seekBackward positions the sources at the last keys below the key, or at
+// the key when it is inclusive, and iterates backward from there
+func (it *Iterator) seekBackward(key []byte, inclusive bool) bool {
+	comparator := it.tx.pager.Comparator()
+	if it.cursor.SeekForPrev(key) && !inclusive && comparator.Compare(it.cursor.Key(), key) == 0 {
+		it.cursor.Prev()
+	}
+
+	// the written key before the first one after the position
+	it.writtenIndex = sort.Search(len(it.written), func(index int) bool {
+		comparison := comparator.Compare(it.written[index], key)
+		return comparison > 0 || (!inclusive && comparison == 0)
+	}) - 1
+	it.forward = false
+	return it.settle()
+}
+
+// settle positions the iterator at the next visible key in the direction of
+// the iteration, the keys of the tree and the keys written by the
+// transaction are merged and the writes hide the committed versions
+func (it *Iterator) settle() bool {
+	comparator := it.tx.pager.Comparator()
+	for {
+		if err := it.cursor.Err(); err != nil {
+			return it.fail(err)
+		}
+
+		var treeKey, writtenKey []byte
+		if it.cursor.Valid() {
+			treeKey = it.cursor.Key()
+		}
+		if it.writtenIndex >= 0 && it.writtenIndex < len(it.written) {
+			writtenKey = it.written[it.writtenIndex]
+		}
+
+		if treeKey == nil && writtenKey == nil {
+			it.valid = false
+			return false
+		}
+
+		fromTree := writtenKey == nil
+		if treeKey != nil && writtenKey != nil {
+			comparison := comparator.Compare(treeKey, writtenKey)
+			if comparison == 0 {
+				it.moveCursor()
+				continue
+			}
+
+			fromTree = (comparison < 0) == it.forward
+		}
+
+		if fromTree {
+			version, found, readErr := it.readCursor()
+			if readErr != nil {
+				return it.fail(readErr)
+			}
+
+			it.moveCursor()
+			if found {
+				return it.position(treeKey, version)
+			}
+			continue
+		}
+
+		version := it.writes[string(writtenKey)]
+		if it.forward {
+			it.writtenIndex += 1
+		} else {
+			it.writtenIndex -= 1
+		}
+
+		if !version.Deleted {
+			return it.position(writtenKey, version)
+		}
+	}
+}
+
+// readCursor returns the version of the key under the cursor the
+// transaction sees, a deleted key is not found
+func (it *Iterator) readCursor() (mvcc.Version, bool, error) {
+	data := it.cursor.Value()
+	if err := it.cursor.Err(); err != nil {
+		return mvcc.Version{}, false, err
+	}
+
+	versions, decodeErr := mvcc.DecodeVersions(data)
+	if decodeErr != nil {
+		return mvcc.Version{}, false, decodeErr
+	}
+
+	version, visible := mvcc.Visible(versions, it.tx.timestamp)
+	return version, visible && !version.Deleted, nil
+}
+
+func (it *Iterator) moveCursor() {
+	if it.forward {
+		it.cursor.Next()
+	} else {
+		it.cursor.Prev()
+	}
+}
+
+func (it *Iterator) position(key []byte, version mvcc.Version) bool {
+	it.key = key
+	it.version = version
+	it.valid = true
+	return true
+}
+
+func cloneRange(keyRange *Range) *Range {
+	cloned := &Range{}
+	if keyRange == nil {
+		return cloned
+	}
+
+	cloned.Start = cloneBound(keyRange.Start)
+	cloned.End = cloneBound(keyRange.End)
+	return cloned
+}
+
+func cloneBound(bound *Bound) *Bound {
+	if bound == nil {
+		return nil
+	}
+
+	return &Bound{Key: bytes.Clone(bound.Key), Inclusive: bound.Inclusive}
+}
@@ -0,0 +1,201 @@
+package bricker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collectIterator(iterator *Iterator, valid bool, forward bool) []string {
+	keys := []string{}
+	for ; valid; valid = stepIterator(iterator, forward) {
+		keys = append(keys, string(iterator.Key()))
+	}
+	return keys
+}
+
+func stepIterator(iterator *Iterator, forward bool) bool {
+	if forward {
+		return iterator.Next()
+	}
+	return iterator.Prev()
+}
+
+func testKeys(start uint32, end uint32, skip ...uint32) []string {
+	keys := []string{}
+	for i := start; i < end; i++ {
+		if !containsKey(skip, i) {
+			keys = append(keys, string(testKey(i)))
+		}
+	}
+	return keys
+}
+
+func containsKey(keys []uint32, key uint32) bool {
+	for _, current := range keys {
+		if current == key {
+			return true
+		}
+	}
+	return false
+}
+
+func reversed(keys []string) []string {
+	reversedKeys := make([]string, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		reversedKeys = append(reversedKeys, keys[i])
+	}
+	return reversedKeys
+}
+
+func TestIteratorReadsVisibleVersions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "iterator.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	for i := uint32(0); i < 500; i++ {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+	}
+	assert.Nil(t, db.Delete(testKey(10)))
+
+	iterator, iteratorErr := db.NewIterator(nil)
+	assert.Nil(t, iteratorErr)
+
+	// the iterator keeps reading its snapshot
+	assert.Nil(t, db.Put(testKey(11), testValue(11, 1)))
+	assert.Nil(t, db.Delete(testKey(12)))
+	assert.Nil(t, db.Put(testKey(1000), testValue(1000, 0)))
+
+	assert.Equal(t, testKeys(0, 500, 10), collectIterator(iterator, iterator.First(), true))
+	assert.Equal(t, reversed(testKeys(0, 500, 10)), collectIterator(iterator, iterator.Last(), false))
+	assert.Nil(t, iterator.Err())
+
+	assert.True(t, iterator.Seek(testKey(11)))
+	assert.Equal(t, testValue(11, 0), iterator.Value())
+	assert.True(t, iterator.Next())
+	assert.Equal(t, testKey(12), iterator.Key())
+	assert.Equal(t, testValue(12, 0), iterator.Value())
+
+	assert.Nil(t, iterator.Close())
+	assert.False(t, iterator.First())
+	assert.ErrorIs(t, iterator.Err(), ErrTxClosed)
+	assert.ErrorIs(t, iterator.Close(), ErrTxClosed)
+}
+
+func TestIteratorRanges(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "iterator_ranges.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	for i := uint32(0); i < 300; i++ {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+	}
+
+	start := testKey(100)
+	keyRange := &Range{Start: &Bound{Key: start, Inclusive: true}, End: &Bound{Key: testKey(200), Inclusive: false}}
+	iterator, iteratorErr := db.NewIterator(keyRange)
+	assert.Nil(t, iteratorErr)
+	defer iterator.Close()
+
+	// the iterator keeps a copy of the bounds
+	start[len(start)-1] = '9'
+
+	assert.Equal(t, testKeys(100, 200), collectIterator(iterator, iterator.First(), true))
+	assert.Equal(t, reversed(testKeys(100, 200)), collectIterator(iterator, iterator.Last(), false))
+	assert.Equal(t, testKeys(150, 200), collectIterator(iterator, iterator.Seek(testKey(150)), true))
+	assert.Equal(t, reversed(testKeys(100, 151)), collectIterator(iterator, iterator.SeekForPrev(testKey(150)), false))
+	assert.Equal(t, testKeys(100, 200), collectIterator(iterator, iterator.Seek(testKey(5)), true))
+	assert.False(t, iterator.Seek(testKey(250)))
+
+	// changing the direction continues from the current key
+	assert.True(t, iterator.Seek(testKey(150)))
+	assert.True(t, iterator.Next())
+	assert.True(t, iterator.Prev())
+	assert.Equal(t, testKey(150), iterator.Key())
+	assert.True(t, iterator.Prev())
+	assert.Equal(t, testKey(149), iterator.Key())
+	assert.True(t, iterator.Next())
+	assert.Equal(t, testKey(150), iterator.Key())
+}
+
+func TestIteratorReadsWritesOfTransaction(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "iterator_tx.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	for i := uint32(0); i < 100; i += 2 {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+	}
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	defer tx.Rollback()
+
+	assert.Nil(t, tx.Insert(testKey(1), testValue(1, 1)))
+	assert.Nil(t, tx.Update(testKey(2), testValue(2, 1)))
+	assert.Nil(t, tx.Delete(testKey(4)))
+	assert.Nil(t, tx.Insert(testKey(101), testValue(101, 1)))
+
+	iterator, iteratorErr := tx.NewIterator(&Range{End: &Bound{Key: testKey(100)}})
+	assert.Nil(t, iteratorErr)
+
+	expected := []string{string(testKey(0)), string(testKey(1)), string(testKey(2))}
+	for i := uint32(6); i < 100; i += 2 {
+		expected = append(expected, string(testKey(i)))
+	}
+	assert.Equal(t, expected, collectIterator(iterator, iterator.First(), true))
+	assert.Equal(t, reversed(expected), collectIterator(iterator, iterator.Last(), false))
+
+	assert.True(t, iterator.Seek(testKey(1)))
+	assert.Equal(t, testValue(1, 1), iterator.Value())
+	assert.True(t, iterator.Next())
+	assert.Equal(t, testValue(2, 1), iterator.Value())
+	assert.True(t, iterator.Next())
+	assert.Equal(t, testKey(6), iterator.Key())
+	assert.True(t, iterator.Prev())
+	assert.Equal(t, testKey(2), iterator.Key())
+
+	// the iterator can't be used after the transaction ended
+	assert.Nil(t, tx.Rollback())
+	assert.False(t, iterator.Next())
+	assert.ErrorIs(t, iterator.Err(), ErrTxClosed)
+	assert.Nil(t, iterator.Close())
+}
+
+func TestIteratorReadsBlobs(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "iterator_blob.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	data := blobData(100000)
+	writeBlob(t, db, testKey(1), data)
+	assert.Nil(t, db.Put(testKey(2), testValue(2, 0)))
+
+	iterator, iteratorErr := db.NewIterator(nil)
+	assert.Nil(t, iteratorErr)
+	defer iterator.Close()
+
+	assert.True(t, iterator.First())
+	assert.Equal(t, data, iterator.Value())
+	assert.True(t, iterator.Next())
+	assert.Equal(t, testValue(2, 0), iterator.Value())
+	assert.False(t, iterator.Next())
+	assert.Nil(t, iterator.Err())
+}
@@ -0,0 +1,261 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/lock"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Tx groups operations that are committed or discarded together. A
+// transaction reads the snapshot taken when it began, the changes made by a
+// writable transaction are only visible to it until Commit. The writes lock
+// their keys exclusively until the transaction ends, a write waiting for the
+// locks of transactions which wait for its own locks fails with ErrDeadlock
+// and rolls the transaction back.
+type Tx struct {
+	db *DB
+	// read only view of the state committed when the transaction began
+	pager *pg.Pager
+	// timestamp of the last commit the transaction sees
+	timestamp uint64
+	// changes of a writable transaction by key, the timestamps are set when
+	// it commits
+	writes map[string]mvcc.Version
+	// owner of the locks of the transaction in the lock manager
+	owner       uint64
+	lockTimeout time.Duration
+	writable    bool
+	closed      bool
+}
+
+func (tx *Tx) Writable() bool {
+	return tx.writable
+}
+
+// SetLockTimeout sets the time the following lock requests of the
+// transaction wait before failing with ErrLockTimeout, a zero timeout uses
+// the LockTimeout of the database and a negative one waits without limit
+func (tx *Tx) SetLockTimeout(timeout time.Duration) {
+	if timeout == 0 {
+		timeout = tx.db.lockTimeout
+	}
+
+	tx.lockTimeout = timeout
+}
+
+// LockKey locks the key until the transaction ends, a shared lock keeps the
+// other transactions from writing the key and an exclusive one from locking
+// it too. Exclusive locks need a writable transaction.
+func (tx *Tx) LockKey(key []byte, exclusive bool) error {
+	return tx.lockRange(lock.Key(key), exclusive)
+}
+
+// LockRange locks the keys from start until before end, including the keys
+// inserted later, until the transaction ends. Nil bounds leave the range
+// unbounded.
+func (tx *Tx) LockRange(start []byte, end []byte, exclusive bool) error {
+	return tx.lockRange(lock.Range{Start: start, End: end}, exclusive)
+}
+
+func (tx *Tx) lockRange(keyRange lock.Range, exclusive bool) error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+
+	mode := lock.Shared
+	if exclusive {
+		if !tx.writable {
+			return ErrTxNotWritable
+		}
+		mode = lock.Exclusive
+	}
+
+	return tx.lock(keyRange, mode)
+}
+
+// lock requests the lock for the transaction, it is rolled back when the
+// request would deadlock
+func (tx *Tx) lock(keyRange lock.Range, mode lock.Mode) error {
+	lockErr := tx.db.locks.Lock(tx.owner, keyRange, mode, tx.lockTimeout)
+	if errors.Is(lockErr, ErrDeadlock) {
+		tx.release()
+	}
+
+	return lockErr
+}
+
+func (tx *Tx) Get(key []byte) ([]byte, error) {
+	if tx.closed {
+		return nil, ErrTxClosed
+	}
+
+	version, found, readErr := tx.read(key)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	return readVersion(tx.pager, version)
+}
+
+// Insert stores the value under the key, ErrKeyExists is returned when the
+// transaction sees a value of the key
+func (tx *Tx) Insert(key []byte, data []byte) error {
+	if err := tx.prepareWrite(key); err != nil {
+		return err
+	}
+
+	_, found, readErr := tx.read(key)
+	if readErr != nil {
+		return readErr
+	}
+
+	if found {
+		return fmt.Errorf("%w: %q", ErrKeyExists, key)
+	}
+
+	tx.write(key, mvcc.Version{Data: bytes.Clone(data)})
+	return nil
+}
+
+// Update replaces the value of the key, ErrKeyNotFound is returned when the
+// transaction sees no value of the key
+func (tx *Tx) Update(key []byte, data []byte) error {
+	if err := tx.prepareWrite(key); err != nil {
+		return err
+	}
+
+	if err := tx.checkFound(key); err != nil {
+		return err
+	}
+
+	tx.write(key, mvcc.Version{Data: bytes.Clone(data)})
+	return nil
+}
+
+func (tx *Tx) Upsert(key []byte, data []byte) error {
+	if err := tx.prepareWrite(key); err != nil {
+		return err
+	}
+
+	tx.write(key, mvcc.Version{Data: bytes.Clone(data)})
+	return nil
+}
+
+func (tx *Tx) Delete(key []byte) error {
+	if err := tx.prepareWrite(key); err != nil {
+		return err
+	}
+
+	if err := tx.checkFound(key); err != nil {
+		return err
+	}
+
+	tx.write(key, mvcc.Version{Deleted: true})
+	return nil
+}
+
+// Commit makes the changes of the transaction durable and visible. The
+// transaction is rolled back with ErrWriteConflict when another transaction
+// committed one of the keys it writes after it began.
+func (tx *Tx) Commit() error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+	defer tx.unlock()
+
+	if !tx.writable || len(tx.writes) == 0 {
+		tx.endSnapshot()
+		return nil
+	}
+
+	return tx.db.commit(tx)
+}
+
+// Rollback discards the changes of the transaction
+func (tx *Tx) Rollback() error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+
+	tx.release()
+	return nil
+}
+
+// read returns the version of the key the transaction sees, a deleted key
+// is not found
+func (tx *Tx) read(key []byte) (mvcc.Version, bool, error) {
+	if version, written := tx.writes[string(key)]; written {
+		return version, !version.Deleted, nil
+	}
+
+	versions, getErr := getVersions(tx.pager, key)
+	if getErr != nil {
+		return mvcc.Version{}, false, getErr
+	}
+
+	version, visible := mvcc.Visible(versions, tx.timestamp)
+	return version, visible && !version.Deleted, nil
+}
+
+func (tx *Tx) write(key []byte, version mvcc.Version) {
+	tx.writes[string(key)] = version
+}
+
+func (tx *Tx) checkFound(key []byte) error {
+	_, found, readErr := tx.read(key)
+	if readErr != nil {
+		return readErr
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %q", ErrKeyNotFound, key)
+	}
+
+	return nil
+}
+
+// prepareWrite checks the transaction can write the key and locks it
+func (tx *Tx) prepareWrite(key []byte) error {
+	if tx.closed {
+		return ErrTxClosed
+	}
+
+	if !tx.writable {
+		return ErrTxNotWritable
+	}
+
+	if len(key) > node.MAX_KEY_SIZE {
+		return ErrKeyTooLarge
+	}
+
+	// the key is locked before it is read, the checks of the write hold
+	// until the transaction ends
+	return tx.lock(lock.Key(key), lock.Exclusive)
+}
+
+func (tx *Tx) release() {
+	tx.endSnapshot()
+	tx.unlock()
+}
+
+// unlock releases the locks of the ended transaction, the locks of the keys
+// are held until its commit is installed
+func (tx *Tx) unlock() {
+	tx.db.locks.ReleaseAll(tx.owner)
+	tx.db.leave()
+}
+
+func (tx *Tx) endSnapshot() {
+	tx.closed = true
+	tx.pager.EndRead()
+	tx.db.oracle.Release(tx.timestamp)
+}
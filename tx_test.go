@@ -0,0 +1,502 @@
+package bricker
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(i uint32) []byte {
+	return []byte(fmt.Sprintf("key%04d", i))
+}
+
+func TestTxCommit(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_commit.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	for i := uint32(1); i <= 200; i++ {
+		assert.Nil(t, tx.Insert(testKey(i), []byte("value")))
+	}
+
+	// uncommitted changes are visible inside the transaction
+	data, getErr := tx.Get(testKey(100))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("value"), data)
+	assert.Nil(t, tx.Commit())
+
+	readTx, readBeginErr := db.Begin(false)
+	assert.Nil(t, readBeginErr)
+	for i := uint32(1); i <= 200; i++ {
+		data, err := readTx.Get(testKey(i))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), data)
+	}
+	assert.Nil(t, readTx.Commit())
+
+	assert.Nil(t, db.Close())
+}
+
+func TestTxRollback(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_rollback.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Insert(testKey(1), []byte("kept")))
+	assert.Nil(t, tx.Commit())
+	pageCount := db.pager.PageCount()
+
+	tx, beginErr = db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Update(testKey(1), []byte("discarded")))
+	// enough inserts to split the root
+	for i := uint32(2); i <= 200; i++ {
+		assert.Nil(t, tx.Insert(testKey(i), []byte("discarded")))
+	}
+	assert.Nil(t, tx.Rollback())
+
+	// pages allocated by the splits are released
+	assert.Equal(t, pageCount, db.pager.PageCount())
+
+	readTx, readBeginErr := db.Begin(false)
+	assert.Nil(t, readBeginErr)
+	defer readTx.Rollback()
+
+	data, getErr := readTx.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("kept"), data)
+
+	_, getErr = readTx.Get(testKey(2))
+	assert.ErrorIs(t, getErr, ErrKeyNotFound)
+}
+
+func TestTxFailedOperationKeepsTransactionUsable(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_failed_operation.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Insert(testKey(1), []byte("one")))
+	assert.ErrorIs(t, tx.Delete(testKey(2)), ErrKeyNotFound)
+	assert.Nil(t, tx.Insert(testKey(3), []byte("three")))
+	assert.Nil(t, tx.Commit())
+
+	readTx, readBeginErr := db.Begin(false)
+	assert.Nil(t, readBeginErr)
+	defer readTx.Rollback()
+
+	for _, key := range []uint32{1, 3} {
+		_, err := readTx.Get(testKey(key))
+		assert.Nil(t, err)
+	}
+}
+
+func TestTxReadOnlyAndClosed(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_read_only.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	readTx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	assert.ErrorIs(t, readTx.Insert(testKey(1), []byte("value")), ErrTxNotWritable)
+	assert.ErrorIs(t, readTx.Delete(testKey(1)), ErrTxNotWritable)
+	assert.Nil(t, readTx.Commit())
+
+	_, getErr := readTx.Get(testKey(1))
+	assert.ErrorIs(t, getErr, ErrTxClosed)
+	assert.ErrorIs(t, readTx.Commit(), ErrTxClosed)
+	assert.ErrorIs(t, readTx.Rollback(), ErrTxClosed)
+}
+
+func TestTxConcurrentReadersAndWriter(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_concurrent.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	counterKey := []byte("counter")
+	assert.Nil(t, db.Put(counterKey, []byte("0")))
+
+	// every transaction of the writer adds a key and bumps the counter, the
+	// large values split the leaves every few transactions
+	const transactions = 200
+	value := make([]byte, 200)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := uint32(1); i <= transactions; i++ {
+			tx, beginErr := db.Begin(true)
+			assert.Nil(t, beginErr)
+			assert.Nil(t, tx.Insert(testKey(i), value))
+			assert.Nil(t, tx.Upsert(counterKey, []byte(fmt.Sprint(i))))
+			assert.Nil(t, tx.Commit())
+		}
+	}()
+
+	// readers only see whole transactions, the keys match the counter
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for counter := uint32(0); counter < transactions; {
+				tx, beginErr := db.Begin(false)
+				assert.Nil(t, beginErr)
+
+				data, getErr := tx.Get(counterKey)
+				assert.Nil(t, getErr)
+				var seen uint32
+				fmt.Sscan(string(data), &seen)
+				assert.GreaterOrEqual(t, seen, counter)
+				counter = seen
+
+				for i := uint32(1); i <= counter; i++ {
+					_, keyErr := tx.Get(testKey(i))
+					assert.Nil(t, keyErr)
+				}
+				_, missingErr := tx.Get(testKey(counter + 1))
+				assert.ErrorIs(t, missingErr, ErrKeyNotFound)
+
+				assert.Nil(t, tx.Commit())
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Nil(t, db.Close())
+}
+
+func TestTxReadOnlySnapshot(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_snapshot.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	const keys = 300
+	for i := uint32(1); i <= keys; i++ {
+		assert.Nil(t, db.Put(testKey(i), []byte("old")))
+	}
+
+	snapshot, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+
+	// the writes commit while the snapshot is open
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := uint32(1); i <= keys; i++ {
+			assert.Nil(t, db.Put(testKey(i), []byte("new")))
+		}
+
+		tx, txErr := db.Begin(true)
+		assert.Nil(t, txErr)
+		for i := uint32(1); i <= keys; i += 2 {
+			assert.Nil(t, tx.Delete(testKey(i)))
+		}
+		assert.Nil(t, tx.Insert(testKey(keys+1), []byte("new")))
+		assert.Nil(t, tx.Commit())
+	}()
+	<-done
+
+	for i := uint32(1); i <= keys; i++ {
+		data, getErr := snapshot.Get(testKey(i))
+		assert.Nil(t, getErr)
+		assert.Equal(t, []byte("old"), data)
+	}
+	_, missingErr := snapshot.Get(testKey(keys + 1))
+	assert.ErrorIs(t, missingErr, ErrKeyNotFound)
+	assert.Nil(t, snapshot.Commit())
+
+	data, getErr := db.Get(testKey(2))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("new"), data)
+	_, deletedErr := db.Get(testKey(1))
+	assert.ErrorIs(t, deletedErr, ErrKeyNotFound)
+}
+
+func TestTxWriteConflict(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_write_conflict.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Nil(t, db.Put(testKey(1), []byte("initial")))
+
+	first, firstErr := db.Begin(true)
+	assert.Nil(t, firstErr)
+	second, secondErr := db.Begin(true)
+	assert.Nil(t, secondErr)
+	assert.Nil(t, first.Update(testKey(1), []byte("first")))
+	assert.Nil(t, second.Insert(testKey(2), []byte("second")))
+
+	// the second transaction waits for the lock of the first one, which
+	// commits the key after the second one began
+	updated := make(chan error)
+	go func() {
+		updated <- second.Update(testKey(1), []byte("second"))
+	}()
+	assert.Nil(t, first.Commit())
+	assert.Nil(t, <-updated)
+	assert.ErrorIs(t, second.Commit(), ErrWriteConflict)
+
+	data, getErr := db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("first"), data)
+	_, missingErr := db.Get(testKey(2))
+	assert.ErrorIs(t, missingErr, ErrKeyNotFound)
+
+	// a put after the transaction began conflicts too, disjoint keys do not
+	third, thirdErr := db.Begin(true)
+	assert.Nil(t, thirdErr)
+	fourth, fourthErr := db.Begin(true)
+	assert.Nil(t, fourthErr)
+	assert.Nil(t, db.Put(testKey(1), []byte("put")))
+	assert.Nil(t, third.Delete(testKey(1)))
+	assert.Nil(t, fourth.Insert(testKey(3), []byte("fourth")))
+	assert.ErrorIs(t, third.Commit(), ErrWriteConflict)
+	assert.Nil(t, fourth.Commit())
+
+	data, getErr = db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("put"), data)
+}
+
+func TestTxWritableSnapshot(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_writable_snapshot.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Nil(t, db.Put(testKey(1), []byte("one")))
+	assert.Nil(t, db.Put(testKey(3), []byte("three")))
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.ErrorIs(t, tx.Insert(testKey(1), []byte("again")), ErrKeyExists)
+
+	// the writes committed after the transaction began are not visible
+	assert.Nil(t, db.Put(testKey(2), []byte("two")))
+	assert.Nil(t, db.Delete(testKey(3)))
+	data, getErr := tx.Get(testKey(3))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("three"), data)
+	_, missingErr := tx.Get(testKey(2))
+	assert.ErrorIs(t, missingErr, ErrKeyNotFound)
+
+	// a key deleted by the transaction can be inserted again
+	assert.Nil(t, tx.Delete(testKey(1)))
+	_, deletedErr := tx.Get(testKey(1))
+	assert.ErrorIs(t, deletedErr, ErrKeyNotFound)
+	assert.Nil(t, tx.Insert(testKey(1), []byte("reinserted")))
+	assert.Nil(t, tx.Commit())
+
+	data, getErr = db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("reinserted"), data)
+}
+
+func TestTxGetReturnsCopyOfWrites(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_get_copy.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	assert.Nil(t, tx.Insert(testKey(1), []byte("Hello")))
+
+	// changing the returned data does not change the value being committed
+	data, getErr := tx.Get(testKey(1))
+	assert.Nil(t, getErr)
+	data[0] = 'X'
+	data, getErr = tx.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("Hello"), data)
+	assert.Nil(t, tx.Commit())
+
+	data, getErr = db.Get(testKey(1))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte("Hello"), data)
+}
+
+func TestTxConcurrentWriters(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_concurrent_writers.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	// every writer retries its increments of the shared counter until they
+	// commit without a conflict, no increment is lost
+	const writers = 8
+	const increments = 50
+	counterKey := []byte("counter")
+	assert.Nil(t, db.Put(counterKey, []byte("0")))
+
+	var wg sync.WaitGroup
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; {
+				tx, beginErr := db.Begin(true)
+				assert.Nil(t, beginErr)
+
+				data, getErr := tx.Get(counterKey)
+				assert.Nil(t, getErr)
+				var counter int
+				fmt.Sscan(string(data), &counter)
+				assert.Nil(t, tx.Update(counterKey, []byte(fmt.Sprint(counter+1))))
+
+				commitErr := tx.Commit()
+				if commitErr == nil {
+					i++
+					continue
+				}
+				assert.ErrorIs(t, commitErr, ErrWriteConflict)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, getErr := db.Get(counterKey)
+	assert.Nil(t, getErr)
+	assert.Equal(t, []byte(fmt.Sprint(writers*increments)), data)
+}
+
+func TestTxLockTimeout(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_lock_timeout.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	holder, holderErr := db.Begin(true)
+	assert.Nil(t, holderErr)
+	assert.Nil(t, holder.Upsert(testKey(1), []byte("holder")))
+	assert.Nil(t, holder.LockRange(testKey(10), testKey(20), false))
+
+	tx, beginErr := db.Begin(true)
+	assert.Nil(t, beginErr)
+	tx.SetLockTimeout(10 * time.Millisecond)
+
+	// the locked key and the keys of the locked range can not be written,
+	// the transaction stays usable
+	assert.ErrorIs(t, tx.Upsert(testKey(1), []byte("tx")), ErrLockTimeout)
+	assert.ErrorIs(t, tx.Insert(testKey(15), []byte("tx")), ErrLockTimeout)
+	assert.ErrorIs(t, tx.LockKey(testKey(12), true), ErrLockTimeout)
+	assert.Nil(t, tx.LockKey(testKey(12), false))
+	assert.Nil(t, tx.Insert(testKey(20), []byte("tx")))
+	assert.Nil(t, tx.Commit())
+
+	// a zero timeout uses the timeout of the database, a negative one waits
+	// until the holder commits
+	waiting, waitingBeginErr := db.Begin(true)
+	assert.Nil(t, waitingBeginErr)
+	waiting.SetLockTimeout(0)
+	assert.Equal(t, DEFAULT_LOCK_TIMEOUT, waiting.lockTimeout)
+	waiting.SetLockTimeout(-1)
+	locked := make(chan error)
+	go func() {
+		locked <- waiting.LockKey(testKey(1), true)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("the lock request did not wait for the holder")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Nil(t, holder.Commit())
+	assert.Nil(t, <-locked)
+	assert.Nil(t, waiting.Rollback())
+
+	readTx, readBeginErr := db.Begin(false)
+	assert.Nil(t, readBeginErr)
+	assert.ErrorIs(t, readTx.LockKey(testKey(1), true), ErrTxNotWritable)
+	assert.Nil(t, readTx.LockKey(testKey(1), false))
+	assert.Nil(t, readTx.Commit())
+	checkValues(t, db, [][]byte{nil, []byte("holder")})
+}
+
+func TestTxDeadlock(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "tx_deadlock.db"
+	defer os.Remove(dbFileName)
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	first, firstErr := db.Begin(true)
+	assert.Nil(t, firstErr)
+	second, secondErr := db.Begin(true)
+	assert.Nil(t, secondErr)
+	assert.Nil(t, first.Insert(testKey(1), []byte("first")))
+	assert.Nil(t, second.Insert(testKey(2), []byte("second")))
+
+	// each transaction waits for the other one, the one closing the cycle
+	// is rolled back and the other one gets the lock
+	txs := []*Tx{first, second}
+	results := make([]error, len(txs))
+	var wg sync.WaitGroup
+	for index := range txs {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			results[index] = txs[index].Insert(testKey(uint32(2-index)), []byte("late"))
+		}(index)
+	}
+	wg.Wait()
+
+	survivor := 0
+	if results[0] != nil {
+		survivor = 1
+	}
+	assert.Nil(t, results[survivor])
+	assert.ErrorIs(t, results[1-survivor], ErrDeadlock)
+	assert.ErrorIs(t, txs[1-survivor].Commit(), ErrTxClosed)
+	assert.Nil(t, txs[survivor].Commit())
+
+	values := [][]byte{nil, []byte("late"), []byte("late")}
+	values[survivor+1] = []byte([]string{"first", "second"}[survivor])
+	checkValues(t, db, values)
+}
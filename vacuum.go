@@ -0,0 +1,117 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/mvcc"
+	"time"
+)
+
+// number of keys purged by a commit of the vacuum, the writers wait for the
+// commit
+const vacuumBatchKeys = 128
+
+// Vacuum purges the versions no open transaction can read anymore and
+// returns the number of purged versions, the keys whose last version is a
+// deletion are removed. It runs in the background every
+// Options.VacuumInterval.
+func (db *DB) Vacuum() (int, error) {
+	tx, beginErr := db.Begin(false)
+	if beginErr != nil {
+		return 0, beginErr
+	}
+	defer tx.Commit()
+
+	// the keys are found on the snapshot and purged in batches, the
+	// versions committed in between are kept by the purge
+	purged := 0
+	snapshots := db.oracle.Snapshots()
+	var batch [][]byte
+	cursor := operations.NewCursor(tx.pager, nil)
+	for valid := cursor.First(); valid; valid = cursor.Next() {
+		versions, decodeErr := mvcc.DecodeVersions(cursor.Value())
+		if decodeErr != nil {
+			return purged, decodeErr
+		}
+
+		if _, dropped := mvcc.Prune(versions, snapshots); len(dropped) == 0 {
+			continue
+		}
+
+		if batch = append(batch, cursor.Key()); len(batch) < vacuumBatchKeys {
+			continue
+		}
+
+		batchPurged, purgeErr := db.purge(batch)
+		purged += batchPurged
+		if purgeErr != nil {
+			return purged, purgeErr
+		}
+		batch = batch[:0]
+	}
+	if err := cursor.Err(); err != nil {
+		return purged, err
+	}
+
+	batchPurged, purgeErr := db.purge(batch)
+	return purged + batchPurged, purgeErr
+}
+
+// purge drops the versions of the keys no open transaction can read in a
+// single commit
+func (db *DB) purge(keys [][]byte) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	db.writers.Lock()
+	defer db.writers.Unlock()
+
+	purged := 0
+	commitErr := db.pager.Atomic(func() error {
+		snapshots := db.oracle.Snapshots()
+		for _, key := range keys {
+			versions, getErr := getVersions(db.pager, key)
+			if getErr != nil {
+				return getErr
+			}
+
+			kept, dropped := mvcc.Prune(versions, snapshots)
+			if len(dropped) == 0 {
+				continue
+			}
+
+			if err := storeVersions(db.pager, key, kept, true); err != nil {
+				return err
+			}
+			if err := freeBlobs(db.pager, dropped); err != nil {
+				return err
+			}
+			purged += len(dropped)
+		}
+
+		return nil
+	})
+	if commitErr != nil {
+		return 0, commitErr
+	}
+
+	return purged, nil
+}
+
+// vacuumEvery runs Vacuum until Close, a failed run is retried by the next
+// one
+func (db *DB) vacuumEvery(interval time.Duration) {
+	defer db.vacuumDone.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stopVacuum:
+			return
+		case <-ticker.C:
+			db.Vacuum()
+		}
+	}
+}
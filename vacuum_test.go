@@ -0,0 +1,145 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionCount returns the number of versions stored under the key
+func versionCount(t *testing.T, db *DB, key []byte) int {
+	tx, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	defer tx.Commit()
+
+	versions, getErr := getVersions(tx.pager, key)
+	assert.Nil(t, getErr)
+	return len(versions)
+}
+
+func TestVacuumPurgesUnreadableVersions(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "vacuum.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.VacuumInterval = -1
+	db, openErr := Open(dbFileName, options)
+	assert.Nil(t, openErr)
+	defer db.Close()
+
+	for i := uint32(0); i < 200; i++ {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 0)))
+	}
+
+	// the snapshot keeps the first versions while the keys are rewritten,
+	// the writes keep the versions the snapshots beginning meanwhile read
+	snapshot, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	for i := uint32(0); i < 200; i++ {
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 1)))
+		assert.Nil(t, db.Put(testKey(i), testValue(i, 2)))
+	}
+	assert.Nil(t, db.Delete(testKey(0)))
+	writeBlob(t, db, testKey(1), blobData(100000))
+	writeBlob(t, db, testKey(1), blobData(200000))
+	for i := uint32(0); i < 3; i++ {
+		assert.Equal(t, 3, versionCount(t, db, testKey(i)))
+	}
+
+	// the versions between the snapshot and the last commit are purged
+	purged, vacuumErr := db.Vacuum()
+	assert.Nil(t, vacuumErr)
+	assert.Equal(t, 200, purged)
+	for i := uint32(0); i < 3; i++ {
+		assert.Equal(t, 2, versionCount(t, db, testKey(i)))
+	}
+
+	data, getErr := snapshot.Get(testKey(0))
+	assert.Nil(t, getErr)
+	assert.Equal(t, testValue(0, 0), data)
+	assert.Nil(t, snapshot.Commit())
+
+	// without snapshots only the last versions are kept and the deleted key
+	// is removed
+	purged, vacuumErr = db.Vacuum()
+	assert.Nil(t, vacuumErr)
+	assert.Equal(t, 200+1, purged)
+	assert.Equal(t, 0, versionCount(t, db, testKey(0)))
+	assert.Equal(t, 1, versionCount(t, db, testKey(1)))
+	assert.Equal(t, 1, versionCount(t, db, testKey(2)))
+
+	_, missingErr := operations.Get(db.pager, testKey(0))
+	assert.ErrorIs(t, missingErr, ErrKeyNotFound)
+	checkValues(t, db, [][]byte{nil, blobData(200000), testValue(2, 2)})
+
+	purged, vacuumErr = db.Vacuum()
+	assert.Nil(t, vacuumErr)
+	assert.Zero(t, purged)
+}
+
+func TestVacuumRunsInBackground(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "vacuum_background.db"
+	defer os.Remove(dbFileName)
+
+	options := DefaultOptions()
+	options.VacuumInterval = 10 * time.Millisecond
+	db, openErr := Open(dbFileName, options)
+	assert.Nil(t, openErr)
+
+	snapshot, beginErr := db.Begin(false)
+	assert.Nil(t, beginErr)
+	for round := 0; round < 3; round++ {
+		assert.Nil(t, db.Put(testKey(1), testValue(1, round)))
+	}
+	assert.Nil(t, snapshot.Commit())
+
+	assert.Eventually(t, func() bool {
+		return versionCount(t, db, testKey(1)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, db.Close())
+}
+
+func TestDBUpgradesUnversionedValues(t *testing.T) {
+	tempDir := os.TempDir()
+	dbFileName := tempDir + "db_unversioned.db"
+	defer os.Remove(dbFileName)
+
+	// a file written before the values were versioned has no commit timestamp
+	pager, pagerErr := pg.NewPager(dbFileName)
+	assert.Nil(t, pagerErr)
+	assert.Nil(t, operations.Init(pager))
+	assert.Nil(t, pager.Atomic(func() error {
+		for i := uint32(0); i < 300; i++ {
+			if err := operations.Upsert(pager, testKey(i), testValue(i, 0)); err != nil {
+				return err
+			}
+		}
+
+		return operations.Upsert(pager, testKey(300), blobData(50000))
+	}))
+	assert.Zero(t, pager.CommitTimestamp())
+	assert.Nil(t, pager.CloseFile())
+
+	db, openErr := Open(dbFileName, nil)
+	assert.Nil(t, openErr)
+	defer db.Close()
+	assert.Equal(t, uint64(1), db.pager.CommitTimestamp())
+
+	values := make([][]byte, 301)
+	for i := uint32(0); i < 300; i++ {
+		values[i] = testValue(i, 0)
+	}
+	values[300] = blobData(50000)
+	checkValues(t, db, values)
+
+	versions, getErr := getVersions(db.pager, testKey(300))
+	assert.Nil(t, getErr)
+	assert.Equal(t, []mvcc.Version{{Timestamp: 1, Data: blobData(50000)}}, versions)
+}
@@ -0,0 +1,127 @@
+package bricker
+
+import (
+	"bricker-db/internal/btree/node"
+	"bricker-db/internal/btree/operations"
+	"bricker-db/internal/mvcc"
+	pg "bricker-db/internal/pager"
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// decodeVersions decodes the versions read by an operation, a missing key
+// has no versions
+func decodeVersions(data []byte, readErr error) ([]mvcc.Version, error) {
+	if errors.Is(readErr, ErrKeyNotFound) {
+		return nil, nil
+	}
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return mvcc.DecodeVersions(data)
+}
+
+// getVersions returns the versions of the key stored in the state the pager
+// reads
+func getVersions(pager *pg.Pager, key []byte) ([]mvcc.Version, error) {
+	return decodeVersions(operations.Get(pager, key))
+}
+
+// writeVersion stores the version in front of the current versions of the
+// key. The versions no snapshot can see anymore are dropped, the key is
+// removed when none is left.
+func (db *DB) writeVersion(pager *pg.Pager, key []byte, version mvcc.Version, versions []mvcc.Version) error {
+	kept, dropped := mvcc.Prune(append([]mvcc.Version{version}, versions...), db.oracle.Snapshots())
+	if err := storeVersions(pager, key, kept, len(versions) > 0); err != nil {
+		return err
+	}
+
+	return freeBlobs(pager, dropped)
+}
+
+func storeVersions(pager *pg.Pager, key []byte, versions []mvcc.Version, exists bool) error {
+	if len(versions) > 0 {
+		return operations.Upsert(pager, key, mvcc.EncodeVersions(versions))
+	}
+
+	if exists {
+		return operations.Delete(pager, key)
+	}
+
+	return nil
+}
+
+// freeBlobs frees the overflow pages of the dropped blob versions, they are
+// freed once the versions are no longer stored so that a crash in between
+// leaks the pages instead of leaving a version pointing to freed pages
+func freeBlobs(pager *pg.Pager, versions []mvcc.Version) error {
+	for _, version := range versions {
+		if !version.Blob {
+			continue
+		}
+
+		pointer, decodeErr := node.DecodeOverflowPointer(version.Data)
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		if err := pager.FreeOverflow(pointer.PageId, pointer.Length); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readVersion returns a copy of the data of the version, the data of a blob
+// is read from its overflow pages. The data of the versions written by a
+// transaction is buffered until it commits and must not be shared.
+func readVersion(pager *pg.Pager, version mvcc.Version) ([]byte, error) {
+	if !version.Blob {
+		return bytes.Clone(version.Data), nil
+	}
+
+	pointer, decodeErr := node.DecodeOverflowPointer(version.Data)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return pager.ReadOverflow(pointer.PageId, pointer.Length)
+}
+
+// upgradeValues stores the values of a database created before the values
+// were versioned as versions of a first commit
+func upgradeValues(pager *pg.Pager) error {
+	if pager.CommitTimestamp() > 0 {
+		return nil
+	}
+
+	return pager.Atomic(func() error {
+		var keys [][]byte
+		cursor := operations.NewCursor(pager, nil)
+		for valid := cursor.First(); valid; valid = cursor.Next() {
+			keys = append(keys, cursor.Key())
+		}
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			data, getErr := operations.Get(pager, key)
+			if getErr != nil {
+				return getErr
+			}
+
+			versions := []mvcc.Version{{Timestamp: 1, Data: data}}
+			if err := operations.Upsert(pager, key, mvcc.EncodeVersions(versions)); err != nil {
+				return fmt.Errorf("failed to upgrade value of key %q: %w", key, err)
+			}
+		}
+
+		pager.SetCommitTimestamp(1)
+		return nil
+	})
+}